@@ -18,6 +18,17 @@ func BenchmarkDotenv_Load(b *testing.B) {
 	}
 }
 
+func BenchmarkDotenv_LoadLargeFile(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config := dotenv.New()
+		err := config.LoadLargeFile("fixtures/large.env", nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkDotenv_instance(b *testing.B) {
 	config := dotenv.New()
 	config.SetConfigFile("fixtures/large.env")
@@ -55,6 +66,44 @@ func BenchmarkDotenv_instance(b *testing.B) {
 	})
 }
 
+func BenchmarkDotenv_IsSet(b *testing.B) {
+	config := dotenv.New()
+	config.SetConfigFile("fixtures/large.env")
+	err := config.Load()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Setenv("BENCH_FEATURE_FLAG", "true")
+
+	for _, key := range []string{"BENCH_FEATURE_FLAG", "DB_USERNAME", "db_username", "DB_USERNAME_NOT_EXIST"} {
+		b.Run(key, func(b *testing.B) {
+			b.Run("IsSet", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_ = config.IsSet(key)
+				}
+			})
+
+			// LookUp is what IsSet used to call
+			b.Run("LookUp", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, _ = config.LookUp(key)
+				}
+			})
+
+			b.Run("IsSet_Parallel", func(b *testing.B) {
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						_ = config.IsSet(key)
+					}
+				})
+			})
+		})
+	}
+}
+
 func BenchmarkDotenv_global(b *testing.B) {
 	dotenv.SetConfigFile("fixtures/large.env")
 	err := dotenv.Load()
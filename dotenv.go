@@ -1,16 +1,31 @@
 package dotenv
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/spf13/cast"
 )
@@ -27,7 +42,7 @@ const (
 // The priority of the sources is the following:
 // 1. env. variables
 // 2. key/value cache/store (loaded from config file or set explicitly with Set())
-// 3. defaults(when using structures)
+// 3. defaults(set explicitly with SetDefault() or when using structures)
 //
 // For example, if values from the following sources were loaded:
 //
@@ -50,16 +65,86 @@ const (
 //
 // DotEnv is safe for concurrent Get___() and Set() operations by multiple goroutines.
 type DotEnv struct {
-	decoder Decoder
+	decoder    Decoder
+	encoder    Encoder
+	fileReader FileReader
+	stdin      io.Reader
+
+	// stdin is read once, its contents are reused by later loads and reloads
+	stdinOnce sync.Once
+	stdinData []byte
+	stdinErr  error
 
 	configFile        string
 	prefix            string
 	allowEmptyEnvVars bool
+	panicOnSetError   bool
+	normalizeKeys     bool
+	allowIncludes     bool
+	preserveKeyCase   bool
+	keyPolicy         KeyPolicy
+	envKeyReplacer    *strings.Replacer
+	validator         func(v any) error
+	unmarshalDebug    func(field, key, source, value string)
+	fileHeader        []string
+	filePerm          os.FileMode
+
+	// parent is the instance a view created with WithOverrides falls through to
+	parent *DotEnv
+
+	mu            sync.RWMutex
+	writeMu       sync.Mutex // serializes writes to config files
+	decodeMu      sync.Mutex // serializes decoding, since decoders such as DefaultDecoder keep state
+	cachedConfig  map[string]any
+	defaults      map[string]any
+	setMiddleware []SetMiddleware
+	ttlOverrides  map[string]ttlValue
+	hasTTL        atomic.Bool // avoids locking on lookups if SetWithTTL was never called
+	converters    map[reflect.Type]TypeConverter
+	extDecoders   map[string]Decoder
+	frozen        atomic.Bool
+
+	// the files, decryptor, decoder and values of the last load
+	loadedFiles   []string
+	loadedDecrypt Decryptor
+	loadedDecoder Decoder
+	loadedConfig  map[string]any
+
+	loadOnce    sync.Once
+	loadOnceErr error
+
+	loads        atomic.Uint64
+	lookupsEnv   atomic.Uint64
+	lookupsCache atomic.Uint64
+	lookupMisses atomic.Uint64
+}
 
-	mu           sync.RWMutex
-	cachedConfig map[string]any
+// Stats holds counters about the usage of a DotEnv instance.
+type Stats struct {
+	// Loads is the number of successful calls to Load.
+	Loads uint64
+	// LookupsEnv is the number of lookups resolved from environment variables.
+	LookupsEnv uint64
+	// LookupsCache is the number of lookups resolved from the config cache or defaults.
+	LookupsCache uint64
+	// LookupMisses is the number of lookups for keys that are not set.
+	LookupMisses uint64
 }
 
+// FileReader reads the contents of the named config file.
+type FileReader func(file string) ([]byte, error)
+
+// SetMiddleware is called by Set and SetE before a value is stored.
+// It receives the normalized key and the value being set and returns
+// the value to store. Returning an error rejects the value.
+type SetMiddleware func(key string, value any) (any, error)
+
+// TypeConverter converts a config value to a value of a custom type for Unmarshal.
+type TypeConverter func(value string) (any, error)
+
+// ErrFrozen is returned when modifying a DotEnv instance after Freeze has been called.
+var ErrFrozen = errors.New("dotenv: config is frozen and cannot be modified")
+
 // global DotEnv instance
 var (
 	_globalMu sync.RWMutex
@@ -80,390 +165,3234 @@ func ReplaceDefault(env *DotEnv) func() {
 	}
 }
 
-// New returns an initialized DotEnv instance.
+// Option configures a DotEnv instance created with New.
+type Option func(e *DotEnv)
+
+// WithEnvKeyReplacer sets the replacer applied to keys before they are looked up
+// in the environment, see SetEnvKeyReplacer.
+func WithEnvKeyReplacer(r *strings.Replacer) Option {
+	return func(e *DotEnv) {
+		e.SetEnvKeyReplacer(r)
+	}
+}
+
+// WithDefaultFile sets the config file loaded by Load when it's called without files,
+// instead of DefaultConfigFile.
+func WithDefaultFile(file string) Option {
+	return func(e *DotEnv) {
+		e.SetConfigFile(file)
+	}
+}
+
+// WithValidator sets the function that validates the structs populated by Unmarshal, see SetValidator.
+func WithValidator(validator func(v any) error) Option {
+	return func(e *DotEnv) {
+		e.SetValidator(validator)
+	}
+}
+
+// WithUnmarshalDebug sets the callback invoked for each field populated by Unmarshal, see SetUnmarshalDebug.
+func WithUnmarshalDebug(debug func(field, key, source, value string)) Option {
+	return func(e *DotEnv) {
+		e.SetUnmarshalDebug(debug)
+	}
+}
+
+// WithDecoder sets the decoder used by Load to decode the config file(s), see SetDecoder.
+func WithDecoder(decoder Decoder) Option {
+	return func(e *DotEnv) {
+		e.SetDecoder(decoder)
+	}
+}
+
+// New returns an initialized DotEnv instance configured with the provided options.
 // This does not load the config file. You call Load() to do that.
-func New() *DotEnv {
-	return &DotEnv{
-		decoder:    &DefaultDecoder{},
-		configFile: DefaultConfigFile,
+func New(opts ...Option) *DotEnv {
+	e := &DotEnv{
+		decoder:      &DefaultDecoder{},
+		encoder:      &DefaultEncoder{},
+		fileReader:   os.ReadFile,
+		stdin:        os.Stdin,
+		filePerm:     DefaultFilePerm,
+		configFile:   DefaultConfigFile,
+		cachedConfig: make(map[string]any),
+		defaults:     make(map[string]any),
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 var utf8BOM = []byte("\uFEFF")
 
 // Load reads the config file(s) and loads the configuration
 // in the order of the files provided.
+// Gzip-compressed files, e.g. .env.gz, are decompressed transparently.
 // It returns os.ErrNotExist if config file does not exist.
 // If no config file is specified, it loads the .env file from the current directory by default.
+// With a decoder that continues on errors, e.g. DefaultDecoder with ContinueOnError enabled,
+// the valid lines are loaded and the *ParseError of each invalid line is returned.
 func Load(files ...string) error {
 	return GetDotEnv().Load(files...)
 }
 
 func (e *DotEnv) Load(files ...string) error {
+	return e.load(files, nil)
+}
+
+// LoadOnce is like Load but loads the config file(s) only once, even when it's called
+// from multiple goroutines or init paths. Subsequent calls don't read any file
+// and return the result of the first call, regardless of the files provided.
+func LoadOnce(files ...string) error {
+	return GetDotEnv().LoadOnce(files...)
+}
+
+func (e *DotEnv) LoadOnce(files ...string) error {
+	e.loadOnce.Do(func() {
+		e.loadOnceErr = e.Load(files...)
+	})
+	return e.loadOnceErr
+}
+
+// Decryptor decrypts the contents of an encrypted config file.
+type Decryptor func(data []byte) ([]byte, error)
+
+// LoadEncrypted is like Load but decrypts the contents of the config file(s)
+// with the provided decryptor before decoding them.
+// This allows loading files encrypted with tools like sops or age.
+func LoadEncrypted(decrypt Decryptor, files ...string) error {
+	return GetDotEnv().LoadEncrypted(decrypt, files...)
+}
+
+func (e *DotEnv) LoadEncrypted(decrypt Decryptor, files ...string) error {
+	return e.load(files, decrypt)
+}
+
+// LoadReader is like Load but reads the config from r.
+// The values are merged into the config cache, but r is not re-read by Reload.
+func LoadReader(r io.Reader) error { return GetDotEnv().LoadReader(r) }
+
+func (e *DotEnv) LoadReader(r io.Reader) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
 	config := make(map[string]any)
-	if len(files) == 0 {
-		files = []string{e.configFile}
+	if err := e.decodeBytes(e.decoder, "reader", data, config); err != nil {
+		return err
+	}
+	config, err = e.processKeys(config)
+	if err != nil {
+		return err
 	}
 
-	for _, file := range files {
-		data, err := os.ReadFile(file)
+	e.mu.Lock()
+	e.mergeConfig(config)
+	e.mu.Unlock()
+
+	e.loads.Add(1)
+
+	return nil
+}
+
+// LoadDirFS is like Load but loads the *.env files in the directory dir of fsys and its subdirectories,
+// in lexical order as visited by fs.WalkDir, so later files override earlier ones. This allows
+// shipping a tree of config fragments with //go:embed, e.g. LoadDirFS(configFS, "config").
+// The values are merged into the config cache, but the files are not re-read by Reload.
+func LoadDirFS(fsys fs.FS, dir string) error { return GetDotEnv().LoadDirFS(fsys, dir) }
+
+func (e *DotEnv) LoadDirFS(fsys fs.FS, dir string) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
+
+	config := make(map[string]any)
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if d.IsDir() || path.Ext(name) != ".env" {
+			return nil
+		}
 
-		data = bytes.TrimPrefix(data, utf8BOM)
-
-		err = e.decoder.Decode(data, config)
+		data, err := fs.ReadFile(fsys, name)
 		if err != nil {
 			return err
 		}
+		if err := e.decodeBytes(e.decoder, name, data, config); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load config dir %s: %w", dir, err)
 	}
 
-	e.mu.Lock()
-	if e.cachedConfig == nil {
-		e.cachedConfig = make(map[string]any)
+	config, err = e.processKeys(config)
+	if err != nil {
+		return err
 	}
 
-	for key, val := range config {
-		e.cachedConfig[key] = val
-	}
+	e.mu.Lock()
+	e.mergeConfig(config)
 	e.mu.Unlock()
 
+	e.loads.Add(1)
+
 	return nil
 }
 
-// LoadWithDecoder is like Load but uses the provided decoder to decode the config file(s).
-func LoadWithDecoder(decoder Decoder, files ...string) error {
-	return GetDotEnv().LoadWithDecoder(decoder, files...)
-}
+// LoadProfile is like Load for a single file with [section] headers, e.g. [dev] and [prod],
+// but only loads the entries of the profile section and the entries before the first header,
+// which are shared by all profiles. This requires the decoder to be a DefaultDecoder.
+func LoadProfile(path, profile string) error { return GetDotEnv().LoadProfile(path, profile) }
 
-func (e *DotEnv) LoadWithDecoder(decoder Decoder, files ...string) error {
-	e.decoder = decoder
-	return e.Load(files...)
-}
+func (e *DotEnv) LoadProfile(path, profile string) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
 
-// GetDotEnv returns the global DotEnv instance which can reconfigured with ReplaceDefault.
-// It's safe for concurrent use.
-func GetDotEnv() *DotEnv {
-	_globalMu.Lock()
-	d := _global
-	_globalMu.Unlock()
+	d, ok := e.decoder.(*DefaultDecoder)
+	if !ok {
+		return fmt.Errorf("dotenv: LoadProfile requires a DefaultDecoder, got %T", e.decoder)
+	}
+	if d.KeepRawValues {
+		// allocate the raw values before copying the decoder, so the copy shares them
+		// and RawValue, which queries the decoder, sees the keys of the profile
+		e.decodeMu.Lock()
+		if d.rawValues == nil {
+			d.rawValues = make(map[string]string)
+		}
+		e.decodeMu.Unlock()
+	}
+	decoder := *d
+	decoder.Profile = profile
 
-	return d
-}
+	config, err := e.readConfig(&decoder, []string{path}, nil)
+	if config == nil {
+		return err
+	}
 
-// SetPrefix defines a prefix that ENVIRONMENT variables will use.
-// E.g. if your prefix is "pro", the env registry will look for env
-// variables that start with "PRO_".
-func SetPrefix(prefix string) { GetDotEnv().SetPrefix(prefix) }
+	e.storeLoaded([]string{path}, nil, &decoder, config)
 
-func (e *DotEnv) SetPrefix(prefix string) {
-	e.prefix = strings.ToUpper(prefix) + "_"
+	return err
 }
 
-// GetPrefix returns the prefix that ENVIRONMENT variables will use which is set with SetPrefix.
-func GetPrefix() string { return GetDotEnv().GetPrefix() }
+// LoadAndApply is like Load but also sets the values of the config cache as environment variables,
+// so code using os.Getenv sees them, see ApplyToEnv.
+func LoadAndApply(overwrite bool, files ...string) error {
+	return GetDotEnv().LoadAndApply(overwrite, files...)
+}
 
-func (e *DotEnv) GetPrefix() string {
-	return strings.TrimSuffix(e.prefix, "_")
+func (e *DotEnv) LoadAndApply(overwrite bool, files ...string) error {
+	if err := e.Load(files...); err != nil {
+		return err
+	}
+	return e.ApplyToEnv(overwrite)
 }
 
-func (e *DotEnv) addPrefix(key string) string {
-	if e.prefix != "" {
-		if !strings.HasPrefix(e.prefix, key) {
-			key = e.prefix + key
+// ApplyToEnv sets the values of the config cache as environment variables.
+// Environment variables that are already set are only replaced if overwrite is true.
+func ApplyToEnv(overwrite bool) error { return GetDotEnv().ApplyToEnv(overwrite) }
+
+func (e *DotEnv) ApplyToEnv(overwrite bool) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for key, value := range e.cachedConfig {
+		if _, ok := os.LookupEnv(key); ok && !overwrite {
+			continue
+		}
+		if err := os.Setenv(key, cast.ToString(value)); err != nil {
+			return fmt.Errorf("failed to set environment variable %s: %w", key, err)
 		}
 	}
-	return key
+	return nil
 }
 
-// AllowEmptyEnv tells Dotenv to consider set, but empty environment variables
-// as valid values instead of falling back to config value.
-// This is set to true by default.
-func AllowEmptyEnv(allowEmptyEnvVars bool) { GetDotEnv().AllowEmptyEnvVars(allowEmptyEnvVars) }
+func (e *DotEnv) load(files []string, decrypt Decryptor) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
 
-func (e *DotEnv) AllowEmptyEnvVars(allowEmptyEnvVars bool) {
-	e.allowEmptyEnvVars = allowEmptyEnvVars
-}
+	if len(files) == 0 {
+		files = []string{e.configFile}
+	}
 
-// SetConfigFile explicitly defines the path, name and extension of the config file.
-// Dotenv will use this and not check .env from the current directory.
-// You need to call Load() to read the config file.
-// Or you could directly load the config file by calling Load("path/to/config/file").
-func SetConfigFile(configFile string) {
-	if _global == nil {
-		_global = New()
+	config, err := e.readConfig(e.decoder, files, decrypt)
+	if config == nil {
+		return err
 	}
-	_global.SetConfigFile(configFile)
-}
 
-func (e *DotEnv) SetConfigFile(configFile string) {
-	e.configFile = configFile
-}
+	e.storeLoaded(files, decrypt, e.decoder, config)
 
-// Unmarshal unmarshals the config file into a struct.
-// Recognizes the following struct tags:
-//   - env:"KEY" to specify the key name to look up in the config file
-//   - default:"value" to specify a default value if the key is not found
-func Unmarshal(v any) error {
-	return GetDotEnv().Unmarshal(v)
+	return err
 }
 
-func (e *DotEnv) Unmarshal(v any) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
-		}
-	}()
+// storeLoaded merges the config loaded from the files into the config cache
+// and records the files, decryptor and decoder so they can be reloaded.
+func (e *DotEnv) storeLoaded(files []string, decrypt Decryptor, decoder Decoder, config map[string]any) {
+	e.mu.Lock()
+	if e.cachedConfig == nil {
+		e.cachedConfig = make(map[string]any)
+	}
 
-	vPtr := reflect.ValueOf(v)
-	val := vPtr.Elem()
+	e.mergeConfig(config)
+	e.loadedFiles = append([]string(nil), files...)
+	e.loadedDecrypt = decrypt
+	e.loadedDecoder = decoder
+	e.loadedConfig = config
+	e.mu.Unlock()
 
-	if vk := val.Kind(); vk != reflect.Struct {
-		return fmt.Errorf("expected a struct, got %T", vk)
-	}
+	e.loads.Add(1)
+}
 
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		fieldVal := val.Field(i)
+// LoadLargeFile is like Load for a single file, but streams the file through the decoder
+// instead of reading it into memory at once, if the decoder supports it, like DefaultDecoder.
+// onProgress, if not nil, is called with the total number of bytes read so far as the file is read.
+// The file is read from the local filesystem, regardless of SetFileReader,
+// and gzip-compressed files and #include directives are not supported.
+func LoadLargeFile(path string, onProgress func(bytesRead int64)) error {
+	return GetDotEnv().LoadLargeFile(path, onProgress)
+}
 
-		getConfigVal := func() string {
-			tag := field.Tag.Get("env")
-			if tag != "" {
-				if envVal := e.GetString(tag); envVal != "" {
-					return envVal
-				}
-			}
-			// set default value
-			if def := field.Tag.Get("default"); def != "" {
-				return def
-			}
-			return ""
-		}
+func (e *DotEnv) LoadLargeFile(path string, onProgress func(bytesRead int64)) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
 
-		if fieldVal.CanAddr() {
-			if m, ok := fieldVal.Addr().Interface().(encoding.TextUnmarshaler); ok {
-				val := getConfigVal()
-				if val == "" {
-					continue
-				}
-				if err := m.UnmarshalText([]byte(getConfigVal())); err != nil {
-					return err
-				}
-				continue
-			}
-		}
+	file, err := expandPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-		if field.Type.Kind() == reflect.Struct {
-			if err := e.Unmarshal(fieldVal.Addr().Interface()); err != nil {
-				return err
-			}
-			continue
-		}
+	r := bufio.NewReaderSize(&progressReader{r: f, onProgress: onProgress}, 64*1024)
+	if bom, _ := r.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		_, _ = r.Discard(len(utf8BOM))
+	}
 
-		configVal := getConfigVal()
-		if configVal == "" {
-			continue
+	config := make(map[string]any)
+	e.decodeMu.Lock()
+	if d, ok := e.decoder.(interface {
+		DecodeReader(r io.Reader, v map[string]any) error
+	}); ok {
+		err = d.DecodeReader(r, config)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(r); err == nil {
+			err = e.decoder.Decode(data, config)
 		}
+	}
+	e.decodeMu.Unlock()
+	if err != nil {
+		return err
+	}
 
-		// set the value based on the field type
-		switch field.Type {
-		case reflect.TypeOf(time.Time{}):
-			fieldVal.Set(reflect.ValueOf(cast.ToTime(configVal)))
-		case reflect.TypeOf(time.Duration(0)):
-			fieldVal.Set(reflect.ValueOf(cast.ToDuration(configVal)))
-		case reflect.TypeOf([]int{}):
-			fieldVal.Set(reflect.ValueOf(cast.ToIntSlice(configVal)))
-		case reflect.TypeOf([]string{}):
-			fieldVal.Set(reflect.ValueOf(cast.ToStringSlice(configVal)))
-		default:
-			switch field.Type.Kind() {
-			case reflect.String:
-				fieldVal.SetString(cast.ToString(configVal))
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				fieldVal.SetInt(cast.ToInt64(configVal))
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				fieldVal.SetUint(cast.ToUint64(configVal))
-			case reflect.Float32, reflect.Float64:
-				fieldVal.SetFloat(cast.ToFloat64(configVal))
-			case reflect.Bool:
-				fieldVal.SetBool(cast.ToBool(configVal))
-			default:
-				return fmt.Errorf("unsupported type %s", field.Type)
-			}
-		}
+	config, err = e.processKeys(config)
+	if err != nil {
+		return err
 	}
 
-	return err
+	e.storeLoaded([]string{path}, nil, e.decoder, config)
+
+	return nil
 }
 
-// Get can retrieve any value given the key to use.
-// Get is case-insensitive for a key.
-// Dotenv will check in the following order:
-// configOverride cache, env, key/value store, config file
-//
-// Get returns an interface. For a specific value use one of the Get___ methods e.g. GetBool(key) for a boolean value
-func Get(key string) any { return GetDotEnv().Get(key) }
+// progressReader reports the number of bytes read from r.
+type progressReader struct {
+	r          io.Reader
+	read       int64
+	onProgress func(bytesRead int64)
+}
 
-func (e *DotEnv) Get(key string) any {
-	val, _ := e.LookUp(key)
-	return val
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		if p.onProgress != nil {
+			p.onProgress(p.read)
+		}
+	}
+	return n, err
 }
 
-// GetString returns the value associated with the key as a string.
-func GetString(key string) string { return GetDotEnv().GetString(key) }
+// Reload re-reads the config file(s) of the last call to Load, in the same order.
+// Keys that were loaded before but no longer exist in the files are removed.
+func Reload() error { return GetDotEnv().Reload() }
 
-func (e *DotEnv) GetString(key string) string {
-	return cast.ToString(e.Get(key))
+func (e *DotEnv) Reload() error {
+	return e.reload()
 }
 
-// GetBool returns the value associated with the key as a boolean.
-func GetBool(key string) bool { return GetDotEnv().GetBool(key) }
+// reload re-reads the files of the last load and replaces the values loaded from them.
+// Keys that were loaded before but no longer exist in the files are removed.
+func (e *DotEnv) reload() error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
 
-func (e *DotEnv) GetBool(key string) bool {
-	return cast.ToBool(e.Get(key))
-}
+	e.mu.RLock()
+	files, decrypt, decoder := e.loadedFiles, e.loadedDecrypt, e.loadedDecoder
+	e.mu.RUnlock()
 
-// GetInt returns the value associated with the key as an integer.
-func GetInt(key string) int { return GetDotEnv().GetInt(key) }
+	if len(files) == 0 {
+		return errors.New("dotenv: no config file has been loaded")
+	}
 
-func (e *DotEnv) GetInt(key string) int {
-	return cast.ToInt(e.Get(key))
-}
+	config, err := e.readConfig(decoder, files, decrypt)
+	if config == nil {
+		return err
+	}
 
-// GetInt32 returns the value associated with the key as an integer.
-func GetInt32(key string) int32 { return GetDotEnv().GetInt32(key) }
+	e.mu.Lock()
+	for key := range e.loadedConfig {
+		if _, ok := config[key]; !ok {
+			delete(e.cachedConfig, e.cacheKey(key))
+		}
+	}
+	e.mergeConfig(config)
+	e.loadedConfig = config
+	e.mu.Unlock()
 
-func (e *DotEnv) GetInt32(key string) int32 {
-	return cast.ToInt32(e.Get(key))
+	e.loads.Add(1)
+
+	return err
 }
 
-// GetInt64 returns the value associated with the key as an integer.
-func GetInt64(key string) int64 { return GetDotEnv().GetInt64(key) }
+// readConfig reads and decodes the config files in order with the decoder.
+// If the decoder continues on errors, e.g. DefaultDecoder with ContinueOnError enabled,
+// and every error is a *ParseError, the decoded config is returned along with the errors.
+func (e *DotEnv) readConfig(decoder Decoder, files []string, decrypt Decryptor) (map[string]any, error) {
+	config := make(map[string]any)
+	var errs []error
+	for _, file := range files {
+		data, origins, err := e.readConfigFile(file, decrypt, nil)
+		if err != nil {
+			return nil, err
+		}
 
-func (e *DotEnv) GetInt64(key string) int64 {
-	return cast.ToInt64(e.Get(key))
+		if err := e.decodeBytes(e.decoderFor(file, decoder), file, data, config); err != nil {
+			err = originalLines(err, file, origins)
+			fileErrs, ok := parseErrors(err)
+			if !ok {
+				return nil, err
+			}
+			errs = append(errs, fileErrs...)
+		}
+	}
+
+	config, err := e.processKeys(config)
+	if err != nil {
+		return nil, err
+	}
+	return config, errors.Join(errs...)
 }
 
-// GetUint returns the value associated with the key as an unsigned integer.
-func GetUint(key string) uint { return GetDotEnv().GetUint(key) }
+// parseErrors returns the errors of a decoder that continued on errors,
+// if every one of them is a *ParseError.
+func parseErrors(err error) ([]error, bool) {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil, false
+	}
 
-func (e *DotEnv) GetUint(key string) uint {
-	return cast.ToUint(e.Get(key))
+	errs := joined.Unwrap()
+	for _, err := range errs {
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			return nil, false
+		}
+	}
+	return errs, true
 }
 
-// GetUint32 returns the value associated with the key as an unsigned integer.
-func GetUint32(key string) uint32 { return GetDotEnv().GetUint32(key) }
-
-func (e *DotEnv) GetUint32(key string) uint32 {
-	return cast.ToUint32(e.Get(key))
+// RegisterDecoderForExt registers the decoder used by Load for the files with the extension ext,
+// e.g. RegisterDecoderForExt(".toml", &TOMLDecoder{}), to support other config formats.
+// The extension is case-insensitive and a trailing .gz is ignored, so app.TOML.gz uses the .toml decoder.
+// Files with other extensions are decoded with the decoder set with SetDecoder, which defaults to DefaultDecoder.
+func RegisterDecoderForExt(ext string, decoder Decoder) {
+	GetDotEnv().RegisterDecoderForExt(ext, decoder)
 }
 
-// GetUint64 returns the value associated with the key as an unsigned integer.
-func GetUint64(key string) uint64 { return GetDotEnv().GetUint64(key) }
+func (e *DotEnv) RegisterDecoderForExt(ext string, decoder Decoder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-func (e *DotEnv) GetUint64(key string) uint64 {
-	return cast.ToUint64(e.Get(key))
+	if e.extDecoders == nil {
+		e.extDecoders = make(map[string]Decoder)
+	}
+	e.extDecoders[strings.ToLower(ext)] = decoder
 }
 
-// GetFloat64 returns the value associated with the key as a float64.
+// decoderFor returns the decoder registered for the extension of the file, falling back to decoder.
+func (e *DotEnv) decoderFor(file string, decoder Decoder) Decoder {
+	ext := strings.ToLower(filepath.Ext(file))
+	if ext == ".gz" {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(file, filepath.Ext(file))))
+	}
+
+	if ext == "" {
+		return decoder
+	}
+	// a view uses the decoders registered on its parent
+	for inst := e; inst != nil; inst = inst.parent {
+		inst.mu.RLock()
+		d, ok := inst.extDecoders[ext]
+		inst.mu.RUnlock()
+		if ok {
+			return d
+		}
+	}
+	return decoder
+}
+
+// LoadCollect is like Load but doesn't stop at the first error.
+// The files that can be read are loaded and the errors of all the files are returned.
+// With a decoder that continues on errors, e.g. DefaultDecoder with ContinueOnError enabled,
+// the valid lines of a malformed file are loaded and each invalid line is reported
+// as a separate *ParseError.
+// Reload only reads the files again that could be read and decoded, including the files
+// whose valid lines were loaded by a decoder that continues on errors.
+func LoadCollect(files ...string) []error {
+	return GetDotEnv().LoadCollect(files...)
+}
+
+func (e *DotEnv) LoadCollect(files ...string) (errs []error) {
+	if e.isFrozen() {
+		return []error{ErrFrozen}
+	}
+
+	if len(files) == 0 {
+		files = []string{e.configFile}
+	}
+
+	config := make(map[string]any)
+	// loaded holds the files that were read and decoded, which Reload reads again
+	var loaded []string
+	for _, file := range files {
+		data, origins, err := e.readConfigFile(file, nil, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := e.decodeBytes(e.decoderFor(file, e.decoder), file, data, config); err != nil {
+			err = originalLines(err, file, origins)
+			fileErrs, ok := parseErrors(err)
+			if !ok {
+				errs = append(errs, err)
+				continue
+			}
+			// the valid lines of the file are loaded
+			errs = append(errs, fileErrs...)
+		}
+		loaded = append(loaded, file)
+	}
+
+	config, err := e.processKeys(config)
+	if err != nil {
+		return append(errs, err)
+	}
+
+	if len(loaded) == 0 {
+		// keep the files of the previous load for Reload
+		e.mu.Lock()
+		if e.cachedConfig == nil {
+			e.cachedConfig = make(map[string]any)
+		}
+		e.mergeConfig(config)
+		e.mu.Unlock()
+		return errs
+	}
+	e.storeLoaded(loaded, nil, e.decoder, config)
+
+	return errs
+}
+
+// readConfigFile reads and decrypts the config file.
+// If includes are allowed, the file is decompressed and its #include directives are expanded,
+// and the origin of every line of the expanded contents is returned to report errors
+// against the file and line they come from.
+// includedBy holds the files that include this file, to detect include cycles.
+func (e *DotEnv) readConfigFile(file string, decrypt Decryptor, includedBy []string) ([]byte, []lineOrigin, error) {
+	data, err := e.readFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if decrypt != nil {
+		data, err = decrypt(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt config file %s: %w", file, err)
+		}
+	}
+
+	if !e.root().allowIncludes {
+		return data, nil, nil
+	}
+
+	// decompress before expanding the includes, which decodeBytes leaves as is
+	data, err = decompress(file, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stack := append(includedBy[:len(includedBy):len(includedBy)], filepath.Clean(file))
+	return e.expandIncludes(file, data, decrypt, stack)
+}
+
+// lineOrigin is the file and line number a line of config with expanded includes comes from.
+type lineOrigin struct {
+	file string
+	line int
+}
+
+// expandIncludes replaces the #include directives in data with the contents of the included files.
+// Relative paths are resolved against the directory of the including file.
+// Directives inside multi-line quoted values are part of the value and are not expanded.
+func (e *DotEnv) expandIncludes(file string, data []byte, decrypt Decryptor, stack []string) ([]byte, []lineOrigin, error) {
+	var (
+		b       bytes.Buffer
+		origins []lineOrigin
+		quote   byte
+	)
+	for i, line := range strings.SplitAfter(string(data), "\n") {
+		include, ok := strings.CutPrefix(strings.TrimSpace(line), "#include ")
+		if !ok || quote != 0 {
+			quote = quoteAfterLine(line, quote)
+			b.WriteString(line)
+			origins = append(origins, lineOrigin{file: file, line: i + 1})
+			continue
+		}
+
+		included := strings.TrimSpace(include)
+		if !filepath.IsAbs(included) && !strings.HasPrefix(included, "~") && !strings.HasPrefix(included, "$") {
+			included = filepath.Join(filepath.Dir(file), included)
+		}
+		for _, f := range stack {
+			if f == filepath.Clean(included) {
+				return nil, nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(stack, " -> "), included)
+			}
+		}
+
+		contents, includedOrigins, err := e.readConfigFile(included, decrypt, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		b.Write(contents)
+		if len(contents) == 0 || contents[len(contents)-1] == '\n' {
+			// the empty line after the last newline isn't part of the expanded contents
+			includedOrigins = includedOrigins[:len(includedOrigins)-1]
+		} else {
+			b.WriteByte('\n')
+		}
+		origins = append(origins, includedOrigins...)
+	}
+	return b.Bytes(), origins, nil
+}
+
+// originalLines maps the line numbers of the ParseErrors in err, which refer to the contents
+// with expanded includes, back to the lines of the file they come from.
+// Errors in included files are prefixed with the name of the file.
+func originalLines(err error, file string, origins []lineOrigin) error {
+	if origins == nil {
+		return err
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		mapped := make([]error, len(errs))
+		for i, err := range errs {
+			mapped[i] = originalLines(err, file, origins)
+		}
+		return errors.Join(mapped...)
+	}
+
+	parseErr, ok := err.(*ParseError)
+	if !ok || parseErr.Line < 1 || parseErr.Line > len(origins) {
+		return err
+	}
+	origin := origins[parseErr.Line-1]
+	mapped := &ParseError{Line: origin.line, Err: parseErr.Err}
+	if origin.file != file {
+		return fmt.Errorf("%s: %w", origin.file, mapped)
+	}
+	return mapped
+}
+
+// decompress decompresses data if it's gzip-compressed and strips a leading UTF-8 BOM.
+// The name of the config source is used in error messages.
+func decompress(name string, data []byte) ([]byte, error) {
+	if isGzip(data) {
+		var err error
+		data, err = gunzip(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress config %s: %w", name, err)
+		}
+	}
+	return bytes.TrimPrefix(data, utf8BOM), nil
+}
+
+// decodeBytes decompresses data and decodes it into config with the decoder.
+// It's shared by all load paths. The name of the config source is used in error messages.
+func (e *DotEnv) decodeBytes(decoder Decoder, name string, data []byte, config map[string]any) error {
+	data, err := decompress(name, data)
+	if err != nil {
+		return err
+	}
+
+	e.decodeMu.Lock()
+	defer e.decodeMu.Unlock()
+
+	return decoder.Decode(data, config)
+}
+
+// processKeys normalizes the keys of the decoded config and applies the key policy.
+func (e *DotEnv) processKeys(config map[string]any) (map[string]any, error) {
+	if e.normalizeKeys {
+		normalized := make(map[string]any, len(config))
+		for key, val := range config {
+			normalized[e.replaceKeyChars(key)] = val
+		}
+		config = normalized
+	}
+
+	return e.applyKeyPolicy(config)
+}
+
+// StdinConfigFile is the config file name that reads the config from stdin, e.g. cat .env | myapp.
+const StdinConfigFile = "-"
+
+// readFile reads the config file after expanding its path.
+// The StdinConfigFile is read from stdin. Since stdin can only be read once,
+// its contents are kept and returned again by later calls, e.g. from Reload.
+func (e *DotEnv) readFile(file string) ([]byte, error) {
+	if file == StdinConfigFile {
+		e.stdinOnce.Do(func() {
+			e.stdinData, e.stdinErr = io.ReadAll(e.stdin)
+		})
+		if e.stdinErr != nil {
+			return nil, fmt.Errorf("failed to read config from stdin: %w", e.stdinErr)
+		}
+		return e.stdinData, nil
+	}
+
+	file, err := expandPath(file)
+	if err != nil {
+		return nil, err
+	}
+	return e.fileReader(file)
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip reports whether data is gzip-compressed.
+func isGzip(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// expandPath expands a leading ~ to the user's home directory
+// and $VAR or ${VAR} to the value of the environment variable.
+func expandPath(file string) (string, error) {
+	if file == "~" || strings.HasPrefix(file, "~/") || strings.HasPrefix(file, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand config file path %s: %w", file, err)
+		}
+		file = home + file[1:]
+	}
+	return os.ExpandEnv(file), nil
+}
+
+// LoadWithDecoder is like Load but uses the provided decoder to decode the config file(s).
+func LoadWithDecoder(decoder Decoder, files ...string) error {
+	return GetDotEnv().LoadWithDecoder(decoder, files...)
+}
+
+func (e *DotEnv) LoadWithDecoder(decoder Decoder, files ...string) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
+	e.SetDecoder(decoder)
+	return e.Load(files...)
+}
+
+// SetDecoder sets the decoder used by Load to decode the config file(s) without loading them.
+// Defaults to DefaultDecoder.
+func SetDecoder(decoder Decoder) { GetDotEnv().SetDecoder(decoder) }
+
+func (e *DotEnv) SetDecoder(decoder Decoder) {
+	if d, ok := decoder.(*DefaultDecoder); ok && e.preserveKeyCase {
+		d.PreserveKeyCase = true
+	}
+	e.decoder = decoder
+}
+
+// SetEncoder sets the encoder used by Save and the other functions writing config files.
+// Defaults to DefaultEncoder.
+func SetEncoder(encoder Encoder) { GetDotEnv().SetEncoder(encoder) }
+
+func (e *DotEnv) SetEncoder(encoder Encoder) {
+	e.encoder = encoder
+}
+
+// SetFileReader sets the function used by Load to read the config file(s).
+// This allows loading config from sources other than the local filesystem, e.g. S3 or GCS.
+// Defaults to os.ReadFile.
+func SetFileReader(reader FileReader) { GetDotEnv().SetFileReader(reader) }
+
+func (e *DotEnv) SetFileReader(reader FileReader) {
+	e.fileReader = reader
+}
+
+// GetDotEnv returns the global DotEnv instance which can reconfigured with ReplaceDefault.
+// It's safe for concurrent use.
+func GetDotEnv() *DotEnv {
+	_globalMu.Lock()
+	d := _global
+	_globalMu.Unlock()
+
+	return d
+}
+
+// SetPrefix defines a prefix that ENVIRONMENT variables will use.
+// E.g. if your prefix is "pro", the env registry will look for env
+// variables that start with "PRO_".
+func SetPrefix(prefix string) { GetDotEnv().SetPrefix(prefix) }
+
+func (e *DotEnv) SetPrefix(prefix string) {
+	e.prefix = strings.ToUpper(prefix) + "_"
+}
+
+// GetPrefix returns the prefix that ENVIRONMENT variables will use which is set with SetPrefix.
+func GetPrefix() string { return GetDotEnv().GetPrefix() }
+
+func (e *DotEnv) GetPrefix() string {
+	return strings.TrimSuffix(e.prefix, "_")
+}
+
+// normalizeKey returns the key as it's stored in the config cache and looked up in the environment.
+func (e *DotEnv) normalizeKey(key string) string {
+	return e.normalizePrefixedKey(e.prefix, key)
+}
+
+// normalizePrefixedKey is like normalizeKey but uses the given prefix instead of the configured one.
+func (e *DotEnv) normalizePrefixedKey(prefix, key string) string {
+	key = addPrefix(prefix, key)
+	if !e.root().preserveKeyCase {
+		key = strings.ToUpper(key)
+	}
+	return e.replaceKeyChars(key)
+}
+
+// PreserveKeyCase tells Dotenv to store keys as written instead of converting them to uppercase,
+// while still matching them case-insensitively: after Set("dbHost", ...), Keys returns dbHost
+// and Get("DBHOST") returns its value. An exact match takes precedence over a case-insensitive one.
+// This is applied to the decoder if it's a DefaultDecoder, including decoders set later with SetDecoder,
+// so keys in config files are kept as written too.
+func PreserveKeyCase(preserveKeyCase bool) { GetDotEnv().PreserveKeyCase(preserveKeyCase) }
+
+func (e *DotEnv) PreserveKeyCase(preserveKeyCase bool) {
+	e.preserveKeyCase = preserveKeyCase
+	if d, ok := e.decoder.(*DefaultDecoder); ok {
+		d.PreserveKeyCase = preserveKeyCase
+	}
+}
+
+// lookupFold finds the value of a key in the config cache or defaults, ignoring case,
+// and returns the source it was found in. The caller must hold mu.
+func (e *DotEnv) lookupFold(key string) (any, string, bool) {
+	for i, m := range []map[string]any{e.cachedConfig, e.defaults} {
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				if i == 0 {
+					return v, sourceCache, true
+				}
+				return v, sourceDefault, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+var keyCharsReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// replaceKeyChars replaces dashes and dots in the key with underscores if NormalizeKeys is enabled.
+func (e *DotEnv) replaceKeyChars(key string) string {
+	if e.normalizeKeys {
+		return keyCharsReplacer.Replace(key)
+	}
+	return key
+}
+
+// NormalizeKeys tells Dotenv to replace dashes and dots in keys with underscores
+// when loading the config file and looking up keys,
+// so my-feature-flag, my.feature.flag and MY_FEATURE_FLAG are the same key.
+func NormalizeKeys(normalizeKeys bool) { GetDotEnv().NormalizeKeys(normalizeKeys) }
+
+func (e *DotEnv) NormalizeKeys(normalizeKeys bool) {
+	e.normalizeKeys = normalizeKeys
+}
+
+// KeyPolicy controls how keys that are not valid environment variable names are handled when loading.
+// A valid name consists of letters, digits and underscores and does not start with a digit.
+type KeyPolicy int
+
+const (
+	// KeyPolicyAllow stores invalid keys as they are. This is the default.
+	KeyPolicyAllow KeyPolicy = iota
+	// KeyPolicyReject makes Load return an error for invalid keys.
+	KeyPolicyReject
+	// KeyPolicySanitize replaces invalid characters with underscores
+	// and prefixes keys that start with a digit with an underscore, e.g. 1FOO-BAR becomes _1FOO_BAR.
+	KeyPolicySanitize
+)
+
+// SetKeyPolicy sets how keys that are not valid environment variable names are handled when loading.
+func SetKeyPolicy(policy KeyPolicy) { GetDotEnv().SetKeyPolicy(policy) }
+
+func (e *DotEnv) SetKeyPolicy(policy KeyPolicy) {
+	e.keyPolicy = policy
+}
+
+// applyKeyPolicy validates or sanitizes the keys of the config according to the key policy.
+func (e *DotEnv) applyKeyPolicy(config map[string]any) (map[string]any, error) {
+	if e.keyPolicy == KeyPolicyAllow {
+		return config, nil
+	}
+
+	result := make(map[string]any, len(config))
+	for key, val := range config {
+		if !isValidKey(key) {
+			if e.keyPolicy == KeyPolicyReject {
+				return nil, fmt.Errorf("invalid key %q: must contain only letters, digits and underscores and not start with a digit", key)
+			}
+			key = sanitizeKey(key)
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+// isValidKey reports whether key is a valid environment variable name.
+func isValidKey(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	return strings.IndexFunc(key, func(r rune) bool { return !isKeyChar(r) }) < 0
+}
+
+// sanitizeKey turns key into a valid environment variable name.
+func sanitizeKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if isKeyChar(r) {
+			return r
+		}
+		return '_'
+	}, key)
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		key = "_" + key
+	}
+	return key
+}
+
+func isKeyChar(r rune) bool {
+	return r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+}
+
+// AllowIncludes tells Dotenv to expand #include directives when loading config files,
+// e.g. "#include ./base.env" is replaced with the contents of base.env at that point.
+// Relative paths are resolved against the directory of the including file.
+// By default, #include lines are comments.
+func AllowIncludes(allowIncludes bool) { GetDotEnv().AllowIncludes(allowIncludes) }
+
+func (e *DotEnv) AllowIncludes(allowIncludes bool) {
+	e.allowIncludes = allowIncludes
+}
+
+// SetEnvKeyReplacer sets a replacer that is applied to keys before they are looked up
+// in the environment. This allows nested config keys to resolve to flat environment variables,
+// e.g. with strings.NewReplacer(".", "_", "-", "_") the key db.host is looked up as DB_HOST.
+func SetEnvKeyReplacer(r *strings.Replacer) { GetDotEnv().SetEnvKeyReplacer(r) }
+
+func (e *DotEnv) SetEnvKeyReplacer(r *strings.Replacer) {
+	e.envKeyReplacer = r
+}
+
+func addPrefix(prefix, key string) string {
+	if prefix != "" {
+		if !strings.HasPrefix(prefix, key) {
+			key = prefix + key
+		}
+	}
+	return key
+}
+
+// AllowEmptyEnv tells Dotenv to consider set, but empty environment variables
+// as valid values instead of falling back to config value.
+// This is set to true by default.
+func AllowEmptyEnv(allowEmptyEnvVars bool) { GetDotEnv().AllowEmptyEnvVars(allowEmptyEnvVars) }
+
+func (e *DotEnv) AllowEmptyEnvVars(allowEmptyEnvVars bool) {
+	e.allowEmptyEnvVars = allowEmptyEnvVars
+}
+
+// SetConfigFile explicitly defines the path, name and extension of the config file.
+// Dotenv will use this and not check .env from the current directory.
+// A leading ~ and environment variables such as $HOME in the path are expanded when loading and saving.
+// Use "-" to read the config from stdin, e.g. cat .env | myapp.
+// You need to call Load() to read the config file.
+// Or you could directly load the config file by calling Load("path/to/config/file").
+func SetConfigFile(configFile string) {
+	if _global == nil {
+		_global = New()
+	}
+	_global.SetConfigFile(configFile)
+}
+
+func (e *DotEnv) SetConfigFile(configFile string) {
+	e.configFile = configFile
+}
+
+// SetConfigFileE is like SetConfigFile but returns an error if the path is empty or only whitespace,
+// leaving the config file unchanged.
+func SetConfigFileE(configFile string) error {
+	if _global == nil {
+		_global = New()
+	}
+	return _global.SetConfigFileE(configFile)
+}
+
+func (e *DotEnv) SetConfigFileE(configFile string) error {
+	if strings.TrimSpace(configFile) == "" {
+		return errors.New("dotenv: config file path cannot be empty")
+	}
+	e.SetConfigFile(configFile)
+	return nil
+}
+
+// ConfigFileUsed returns the expanded path of the last config file that was successfully loaded,
+// or an empty string if no config file has been loaded.
+// When multiple files are loaded at once, it's the last one of them.
+func ConfigFileUsed() string { return GetDotEnv().ConfigFileUsed() }
+
+func (e *DotEnv) ConfigFileUsed() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if len(e.loadedFiles) == 0 {
+		return ""
+	}
+	file := e.loadedFiles[len(e.loadedFiles)-1]
+	if expanded, err := expandPath(file); err == nil {
+		return expanded
+	}
+	return file
+}
+
+// Unmarshal unmarshals the config file into a struct or a *map[string]string.
+// When unmarshalling into a map, it's populated with all the resolved keys and values, see UnmarshalMap.
+//
+// Recognizes the following struct tags:
+//   - env:"KEY" to specify the key name to look up in the config file
+//   - default:"value" to specify a default value if the key is not set.
+//     A key that is set to an empty value, e.g. NAME=, leaves the field unchanged instead.
+//
+// Fields implementing EnvUnmarshaler or encoding.TextUnmarshaler are populated with
+// their UnmarshalEnv or UnmarshalText methods.
+//
+// A default value can reference other fields of the same struct with ${KEY},
+// where KEY is the env tag or name of the referenced field (case-insensitive),
+// e.g. default:"http://${HOST}:${PORT}".
+// References that don't match a field are looked up in the config.
+//
+// A map[string]T field, where T is a struct, is populated from the keys made of the env tag
+// or name of the field, the map key and the env tag of a field of T, e.g. SERVICES_WEB_PORT and
+// SERVICES_API_PORT populate the "web" and "api" entries of a Services field. The map keys are lowercased.
+//
+// If a validator is set with SetValidator, it's run on v after it's populated.
+func Unmarshal(v any) error {
+	return GetDotEnv().Unmarshal(v)
+}
+
+func (e *DotEnv) Unmarshal(v any) error {
+	if m, ok := v.(*map[string]string); ok {
+		return e.UnmarshalMap(m)
+	}
+	if errs := e.unmarshal(v, e.prefix, false); len(errs) > 0 {
+		return errs[0]
+	}
+	return e.validate(v)
+}
+
+// UnmarshalWithPrefix is like Unmarshal but looks up the keys under prefix instead of the prefix set with SetPrefix,
+// e.g. to populate several structs of the same type from PRIMARY_DB_HOST and REPLICA_DB_HOST.
+// An empty prefix looks up the keys without a prefix. The prefix of e is not modified,
+// so it's safe to call concurrently. Fields implementing EnvUnmarshaler still use the prefix of e.
+func UnmarshalWithPrefix(v any, prefix string) error {
+	return GetDotEnv().UnmarshalWithPrefix(v, prefix)
+}
+
+func (e *DotEnv) UnmarshalWithPrefix(v any, prefix string) error {
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
+	if errs := e.unmarshal(v, prefix, false); len(errs) > 0 {
+		return errs[0]
+	}
+	return e.validate(v)
+}
+
+// RegisterTypeConverter registers a converter used by Unmarshal to populate fields of type t,
+// e.g. reflect.TypeOf(uuid.UUID{}). Registered converters take precedence over
+// the built-in conversions and encoding.TextUnmarshaler.
+func RegisterTypeConverter(t reflect.Type, convert TypeConverter) {
+	GetDotEnv().RegisterTypeConverter(t, convert)
+}
+
+func (e *DotEnv) RegisterTypeConverter(t reflect.Type, convert TypeConverter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.converters == nil {
+		e.converters = make(map[reflect.Type]TypeConverter)
+	}
+	e.converters[t] = convert
+}
+
+// isNestedStruct reports whether the field is a struct whose fields are unmarshalled recursively,
+// as opposed to a struct type that is converted from a single value.
+func (e *DotEnv) isNestedStruct(addr any, t reflect.Type) bool {
+	if _, ok := addr.(encoding.TextUnmarshaler); ok || t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := e.typeConverter(t)
+	return !ok
+}
+
+// typeConverter returns the converter registered for t, if any.
+// A view uses the converters registered on its parent.
+func (e *DotEnv) typeConverter(t reflect.Type) (TypeConverter, bool) {
+	for inst := e; inst != nil; inst = inst.parent {
+		inst.mu.RLock()
+		convert, ok := inst.converters[t]
+		inst.mu.RUnlock()
+		if ok {
+			return convert, true
+		}
+	}
+	return nil, false
+}
+
+// SetUnmarshalDebug sets a callback invoked by Unmarshal and UnmarshalCollect for each field
+// they resolve, to diagnose why a field isn't populated as expected. It's called with the name
+// of the field, the key it's looked up with, the value and its source, which is one of:
+//   - "env" if the value is an environment variable
+//   - "cache" if the value is from a config file or Set
+//   - "default" if the value is a SetDefault value or the default tag of the field
+//   - "skipped" if the field has no value and is left unchanged
+//
+// The key is empty for fields without an env tag. Use nil to disable the callback.
+func SetUnmarshalDebug(debug func(field, key, source, value string)) {
+	GetDotEnv().SetUnmarshalDebug(debug)
+}
+
+func (e *DotEnv) SetUnmarshalDebug(debug func(field, key, source, value string)) {
+	e.unmarshalDebug = debug
+}
+
+// SetValidator sets a function that validates the structs populated by Unmarshal and UnmarshalCollect,
+// e.g. the Struct method of a github.com/go-playground/validator instance
+// to honor validate:"required,min=1" tags.
+func SetValidator(validator func(v any) error) { GetDotEnv().SetValidator(validator) }
+
+func (e *DotEnv) SetValidator(validator func(v any) error) {
+	e.validator = validator
+}
+
+// validate runs the validator, if any, on v.
+func (e *DotEnv) validate(v any) error {
+	if e.validator == nil {
+		return nil
+	}
+	if err := e.validator(v); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// EnvUnmarshaler is implemented by types that populate themselves from the config.
+// If a pointer to a struct field implements it, Unmarshal calls UnmarshalEnv with the
+// field's env tag instead of converting the config value.
+type EnvUnmarshaler interface {
+	UnmarshalEnv(e *DotEnv, key string) error
+}
+
+// UnmarshalMap populates m with all the keys in the config cache and their resolved values,
+// with environment variables taking precedence. m is allocated if it's nil.
+func UnmarshalMap(m *map[string]string) error { return GetDotEnv().UnmarshalMap(m) }
+
+func (e *DotEnv) UnmarshalMap(m *map[string]string) error {
+	if m == nil {
+		return errors.New("expected a non-nil pointer to a map")
+	}
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+
+	for key, val := range e.resolvedConfig() {
+		(*m)[key] = val
+	}
+	return nil
+}
+
+// UnmarshalCollect is like Unmarshal but doesn't stop at the first field that fails to unmarshal.
+// It returns the errors of all the fields that failed, each naming the field.
+func UnmarshalCollect(v any) []error {
+	return GetDotEnv().UnmarshalCollect(v)
+}
+
+func (e *DotEnv) UnmarshalCollect(v any) []error {
+	if errs := e.unmarshal(v, e.prefix, true); len(errs) > 0 {
+		return errs
+	}
+	if err := e.validate(v); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// unmarshal unmarshals the config into the struct v, looking up the keys under prefix.
+// If collect is false, it stops at the first error.
+func (e *DotEnv) unmarshal(v any, prefix string, collect bool) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = append(errs, fmt.Errorf("%v", r))
+		}
+	}()
+
+	vPtr := reflect.ValueOf(v)
+	if vPtr.Kind() != reflect.Pointer || vPtr.IsNil() {
+		return []error{fmt.Errorf("expected a pointer to a struct, got %T", v)}
+	}
+	val := vPtr.Elem()
+
+	if vk := val.Kind(); vk != reflect.Struct {
+		return []error{fmt.Errorf("expected a struct, got %T", v)}
+	}
+
+	typ := val.Type()
+	resolver := newFieldResolver(e, typ, prefix)
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+		if !field.IsExported() {
+			// unexported fields cannot be set
+			continue
+		}
+
+		var fieldErrs []error
+		addr := fieldVal.Addr().Interface()
+		if m, ok := addr.(EnvUnmarshaler); ok {
+			if err := m.UnmarshalEnv(e, field.Tag.Get("env")); err != nil {
+				fieldErrs = []error{fmt.Errorf("field %s: %w", field.Name, err)}
+			}
+		} else if isStructMap(field.Type) {
+			fieldErrs = e.unmarshalStructMap(fieldVal, field, prefix, collect)
+		} else if e.isNestedStruct(addr, field.Type) {
+			fieldErrs = e.unmarshal(addr, prefix, collect)
+		} else if err := e.unmarshalField(resolver, i, fieldVal); err != nil {
+			fieldErrs = []error{err}
+		}
+
+		errs = append(errs, fieldErrs...)
+		if len(errs) > 0 && !collect {
+			return errs
+		}
+	}
+
+	return errs
+}
+
+// isStructMap reports whether t is a map of structs with string keys.
+func isStructMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct
+}
+
+// unmarshalStructMap populates a map of structs from the keys PREFIX_NAME_KEY, where PREFIX is
+// the env tag or name of the field, NAME is a map key and KEY is the env tag of a field of the struct.
+func (e *DotEnv) unmarshalStructMap(fieldVal reflect.Value, field reflect.StructField, prefix string, collect bool) (errs []error) {
+	name := field.Tag.Get("env")
+	if name == "" {
+		name = field.Name
+	}
+	groupPrefix := e.normalizePrefixedKey(prefix, name) + "_"
+
+	elemType := field.Type.Elem()
+	var tags []string
+	for i := 0; i < elemType.NumField(); i++ {
+		if tag := elemType.Field(i).Tag.Get("env"); tag != "" {
+			tags = append(tags, e.normalizePrefixedKey("", tag))
+		}
+	}
+	// match the longest tags first, so PORT doesn't shadow ADMIN_PORT
+	sort.Slice(tags, func(i, j int) bool { return len(tags[i]) > len(tags[j]) })
+
+	seen := make(map[string]bool)
+	var entries []string
+	for _, key := range e.keysWithPrefix(groupPrefix) {
+		rest := strings.TrimPrefix(key, groupPrefix)
+		for _, tag := range tags {
+			if entry, ok := strings.CutSuffix(rest, "_"+tag); ok && entry != "" {
+				if !seen[entry] {
+					seen[entry] = true
+					entries = append(entries, entry)
+				}
+				break
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.Strings(entries)
+
+	if fieldVal.IsNil() {
+		fieldVal.Set(reflect.MakeMap(field.Type))
+	}
+	for _, entry := range entries {
+		elem := reflect.New(elemType)
+		errs = append(errs, e.unmarshal(elem.Interface(), groupPrefix+entry+"_", collect)...)
+		fieldVal.SetMapIndex(reflect.ValueOf(strings.ToLower(entry)), elem.Elem())
+		if len(errs) > 0 && !collect {
+			return errs
+		}
+	}
+	return errs
+}
+
+// keysWithPrefix returns the keys in the config cache, the defaults and the environment variables
+// that start with prefix.
+func (e *DotEnv) keysWithPrefix(prefix string) []string {
+	var keys []string
+	if e.parent != nil {
+		keys = e.parent.keysWithPrefix(prefix)
+	}
+	e.mu.RLock()
+	for _, m := range []map[string]any{e.cachedConfig, e.defaults} {
+		for key := range m {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	e.mu.RUnlock()
+	if e.parent != nil {
+		return keys
+	}
+
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// unmarshalField sets the i-th field of a struct from its config value.
+func (e *DotEnv) unmarshalField(resolver *fieldResolver, i int, fieldVal reflect.Value) error {
+	field := resolver.typ.Field(i)
+
+	configVal, err := resolver.resolve(i)
+	if err != nil {
+		return err
+	}
+	if e.unmarshalDebug != nil {
+		e.unmarshalDebug(field.Name, resolver.key(i), resolver.sources[i], configVal)
+	}
+	// unsupported fields are reported even if there's no value to set
+	if !e.isSupportedType(fieldVal.Addr().Interface(), field.Type) {
+		return fmt.Errorf("field %s: unsupported type %s", field.Name, field.Type)
+	}
+	if configVal == "" {
+		// a key that is set to an empty value resets the field to its zero value
+		if source := resolver.sources[i]; source == sourceEnv || source == sourceCache {
+			fieldVal.Set(reflect.Zero(field.Type))
+		}
+		return nil
+	}
+
+	if convert, ok := e.typeConverter(field.Type); ok {
+		value, err := convert(configVal)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		rv := reflect.ValueOf(value)
+		if !rv.IsValid() || !rv.Type().ConvertibleTo(field.Type) {
+			return fmt.Errorf("field %s: converter returned %T, expected %s", field.Name, value, field.Type)
+		}
+		fieldVal.Set(rv.Convert(field.Type))
+		return nil
+	}
+
+	if m, ok := fieldVal.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := m.UnmarshalText([]byte(configVal)); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		return nil
+	}
+
+	// set the value based on the field type
+	var value any
+	switch field.Type {
+	case reflect.TypeOf(time.Time{}):
+		value, err = cast.ToTimeE(configVal)
+	case reflect.TypeOf(time.Duration(0)):
+		value, err = cast.ToDurationE(configVal)
+	case reflect.TypeOf([]int{}):
+		value = cast.ToIntSlice(configVal)
+	case reflect.TypeOf([]string{}):
+		value = cast.ToStringSlice(configVal)
+	case reflect.TypeOf(map[string]string{}):
+		value, err = parseStringMap(configVal)
+	case reflect.TypeOf(map[string]int{}):
+		value, err = parseIntMap(configVal)
+	case reflect.TypeOf(&url.URL{}):
+		value, err = url.Parse(configVal)
+	case reflect.TypeOf(&regexp.Regexp{}):
+		value, err = regexp.Compile(configVal)
+	default:
+		if field.Type.Kind() == reflect.Array {
+			value, err = toArray(configVal, field.Type)
+		} else {
+			value, err = castToKind(configVal, field.Type.Kind())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("field %s: %w", field.Name, err)
+	}
+
+	fieldVal.Set(reflect.ValueOf(value).Convert(field.Type))
+	return nil
+}
+
+var errUnsupportedType = errors.New("unsupported type")
+
+// isSupportedType reports whether unmarshalField can set a field of type t from a config value.
+// addr is the address of the field.
+func (e *DotEnv) isSupportedType(addr any, t reflect.Type) bool {
+	if _, ok := e.typeConverter(t); ok {
+		return true
+	}
+	if _, ok := addr.(encoding.TextUnmarshaler); ok {
+		return true
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(time.Duration(0)),
+		reflect.TypeOf([]int{}), reflect.TypeOf([]string{}),
+		reflect.TypeOf(map[string]string{}), reflect.TypeOf(map[string]int{}),
+		reflect.TypeOf(&url.URL{}), reflect.TypeOf(&regexp.Regexp{}):
+		return true
+	}
+	if t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	_, err := castToKind("", t.Kind())
+	return !errors.Is(err, errUnsupportedType)
+}
+
+// castToKind converts value to a value of the basic kind.
+func castToKind(value string, kind reflect.Kind) (any, error) {
+	switch kind {
+	case reflect.String:
+		return value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cast.ToInt64E(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cast.ToUint64E(value)
+	case reflect.Float32, reflect.Float64:
+		return cast.ToFloat64E(value)
+	case reflect.Bool:
+		return cast.ToBoolE(value)
+	default:
+		return nil, errUnsupportedType
+	}
+}
+
+// toArray converts a comma-separated value to an array of the given type.
+// The number of elements must match the length of the array.
+func toArray(value string, typ reflect.Type) (any, error) {
+	elems := toSlice(value)
+	if len(elems) != typ.Len() {
+		return nil, fmt.Errorf("expected %d elements, got %d", typ.Len(), len(elems))
+	}
+
+	arr := reflect.New(typ).Elem()
+	for i, elem := range elems {
+		v, err := castToKind(strings.TrimSpace(elem), typ.Elem().Kind())
+		if err != nil {
+			return nil, err
+		}
+		arr.Index(i).Set(reflect.ValueOf(v).Convert(typ.Elem()))
+	}
+	return arr.Interface(), nil
+}
+
+// parseIntMap parses a k:v,k:v value into a map of int values.
+func parseIntMap(value string) (map[string]int, error) {
+	strs, err := parseStringMap(value)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]int, len(strs))
+	for k, v := range strs {
+		if m[k], err = cast.ToIntE(v); err != nil {
+			return nil, fmt.Errorf("invalid map value for %q: %w", k, err)
+		}
+	}
+	return m, nil
+}
+
+var fieldRefRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// fieldResolver resolves the config values of the fields of a struct,
+// expanding references to other fields in default values.
+type fieldResolver struct {
+	e      *DotEnv
+	typ    reflect.Type
+	prefix string
+
+	fields    map[string]int
+	values    map[int]string
+	sources   map[int]string
+	resolving map[int]bool
+}
+
+func newFieldResolver(e *DotEnv, typ reflect.Type, prefix string) *fieldResolver {
+	r := &fieldResolver{
+		e:         e,
+		typ:       typ,
+		prefix:    prefix,
+		fields:    make(map[string]int),
+		values:    make(map[int]string),
+		sources:   make(map[int]string),
+		resolving: make(map[int]bool),
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		r.fields[strings.ToUpper(field.Name)] = i
+		if tag := field.Tag.Get("env"); tag != "" {
+			r.fields[strings.ToUpper(tag)] = i
+		}
+	}
+	return r
+}
+
+// resolve returns the config value of the i-th field, falling back to its default value.
+func (r *fieldResolver) resolve(i int) (string, error) {
+	if val, ok := r.values[i]; ok {
+		return val, nil
+	}
+
+	field := r.typ.Field(i)
+	if r.resolving[i] {
+		return "", fmt.Errorf("cycle detected in default value of field %s", field.Name)
+	}
+
+	if tag := field.Tag.Get("env"); tag != "" {
+		// a key that is set, even to an empty value, takes precedence over the default
+		if envVal, source, ok := r.lookup(tag); ok {
+			r.values[i] = envVal
+			r.sources[i] = source
+			return envVal, nil
+		}
+	}
+
+	// set default value
+	def := field.Tag.Get("default")
+	if strings.Contains(def, "${") {
+		r.resolving[i] = true
+		var err error
+		def = fieldRefRegex.ReplaceAllStringFunc(def, func(ref string) string {
+			name := fieldRefRegex.FindStringSubmatch(ref)[1]
+			idx, ok := r.fields[strings.ToUpper(name)]
+			if !ok {
+				val, _, _ := r.lookup(name)
+				return val
+			}
+			val, resolveErr := r.resolve(idx)
+			if resolveErr != nil && err == nil {
+				err = resolveErr
+			}
+			return val
+		})
+		r.resolving[i] = false
+		if err != nil {
+			return "", err
+		}
+	}
+
+	r.values[i] = def
+	r.sources[i] = sourceDefault
+	if def == "" {
+		r.sources[i] = sourceSkipped
+	}
+	return def, nil
+}
+
+// lookup returns the config value of the key under the prefix of the resolver and its source.
+func (r *fieldResolver) lookup(key string) (string, string, bool) {
+	val, source, ok := r.e.lookupSource(r.prefix, key)
+	return cast.ToString(val), source, ok
+}
+
+// key returns the key the i-th field is looked up with, if any.
+func (r *fieldResolver) key(i int) string {
+	if tag := r.typ.Field(i).Tag.Get("env"); tag != "" {
+		return r.e.normalizePrefixedKey(r.prefix, tag)
+	}
+	return ""
+}
+
+// Get can retrieve any value given the key to use.
+// Get is case-insensitive for a key.
+// Dotenv will check in the following order:
+// configOverride cache, env, key/value store, config file
+//
+// Get returns an interface. For a specific value use one of the Get___ methods e.g. GetBool(key) for a boolean value
+func Get(key string) any { return GetDotEnv().Get(key) }
+
+func (e *DotEnv) Get(key string) any {
+	val, _ := e.LookUp(key)
+	return val
+}
+
+var expandStringRegex = regexp.MustCompile(`(\\)?\$(?:\{([A-Za-z0-9_.]+)(?:(:?-)([^}]*))?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// ExpandString expands the references to config values in s with the values from
+// the config cache and environment variables, as returned by GetString:
+//   - $VAR and ${VAR} are replaced with the value of VAR, or an empty string if it's not set
+//   - ${VAR:-default} is replaced with default if VAR is not set or empty
+//   - ${VAR-default} is replaced with default if VAR is not set
+//   - \$VAR is kept literally as $VAR
+func ExpandString(s string) string { return GetDotEnv().ExpandString(s) }
+
+func (e *DotEnv) ExpandString(s string) string {
+	return expandStringRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		submatch := expandStringRegex.FindStringSubmatch(ref)
+		if submatch[1] != "" {
+			return ref[1:]
+		}
+
+		name, op, def := submatch[2], submatch[3], submatch[4]
+		if name == "" {
+			name = submatch[5]
+		}
+
+		val, ok := e.LookUp(name)
+		value := cast.ToString(val)
+		switch {
+		case op == ":-" && value == "", op == "-" && !ok:
+			return def
+		default:
+			return value
+		}
+	})
+}
+
+// GetString returns the value associated with the key as a string.
+// The value is returned verbatim, including any surrounding whitespace.
+func GetString(key string) string { return GetDotEnv().GetString(key) }
+
+func (e *DotEnv) GetString(key string) string {
+	return cast.ToString(e.Get(key))
+}
+
+// Getf is like Get but builds the key with fmt.Sprintf, e.g. Getf("TENANT_%d_NAME", id).
+func Getf(format string, args ...any) any { return GetDotEnv().Getf(format, args...) }
+
+func (e *DotEnv) Getf(format string, args ...any) any {
+	return e.Get(fmt.Sprintf(format, args...))
+}
+
+// GetStringf is like GetString but builds the key with fmt.Sprintf.
+func GetStringf(format string, args ...any) string { return GetDotEnv().GetStringf(format, args...) }
+
+func (e *DotEnv) GetStringf(format string, args ...any) string {
+	return e.GetString(fmt.Sprintf(format, args...))
+}
+
+// GetIntf is like GetInt but builds the key with fmt.Sprintf.
+func GetIntf(format string, args ...any) int { return GetDotEnv().GetIntf(format, args...) }
+
+func (e *DotEnv) GetIntf(format string, args ...any) int {
+	return e.GetInt(fmt.Sprintf(format, args...))
+}
+
+// GetBoolf is like GetBool but builds the key with fmt.Sprintf.
+func GetBoolf(format string, args ...any) bool { return GetDotEnv().GetBoolf(format, args...) }
+
+func (e *DotEnv) GetBoolf(format string, args ...any) bool {
+	return e.GetBool(fmt.Sprintf(format, args...))
+}
+
+// GetStringTrimmed returns the value associated with the key as a string
+// with surrounding whitespace removed.
+func GetStringTrimmed(key string) string { return GetDotEnv().GetStringTrimmed(key) }
+
+func (e *DotEnv) GetStringTrimmed(key string) string {
+	return strings.TrimSpace(e.GetString(key))
+}
+
+// GetSecret returns the value associated with the key as bytes, together with a function
+// that zeroes them. Call destroy as soon as the secret is no longer needed to limit
+// its lifetime in memory. This is best-effort, as copies of the value held by the
+// config cache or the environment are not affected.
+func GetSecret(key string) (secret []byte, destroy func()) { return GetDotEnv().GetSecret(key) }
+
+func (e *DotEnv) GetSecret(key string) (secret []byte, destroy func()) {
+	secret = []byte(e.GetString(key))
+	return secret, func() {
+		clear(secret)
+	}
+}
+
+// GetInferred returns the value associated with the key converted to the type its shape suggests:
+//   - a bool for true or false, ignoring case
+//   - an int for base 10 integers with an optional sign, e.g. 42 or -7, unless they have
+//     a leading zero, so values like file modes (0755) and zip codes are kept as strings
+//   - a float64 for numbers with a decimal point, e.g. 1.0 or .5
+//   - a string for anything else, including an empty value
+//
+// It returns nil if the key is not set.
+func GetInferred(key string) any { return GetDotEnv().GetInferred(key) }
+
+func (e *DotEnv) GetInferred(key string) any {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return nil
+	}
+	return inferValue(cast.ToString(val))
+}
+
+// inferValue converts value to a bool, int, float64 or string based on its shape.
+func inferValue(value string) any {
+	if strings.EqualFold(value, "true") {
+		return true
+	}
+	if strings.EqualFold(value, "false") {
+		return false
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return value
+	}
+
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if strings.Contains(value, ".") {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// GetRune returns the first character of the value associated with the key,
+// or 0 if the value is empty. This is useful for delimiter settings, e.g. CSV_DELIM=;.
+func GetRune(key string) rune { return GetDotEnv().GetRune(key) }
+
+func (e *DotEnv) GetRune(key string) rune {
+	r, _ := utf8.DecodeRuneInString(e.GetString(key))
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
+
+// GetRuneE returns the value associated with the key as a rune.
+// It returns an error if the key is not set or the value is empty or not a single character.
+func GetRuneE(key string) (rune, error) { return GetDotEnv().GetRuneE(key) }
+
+func (e *DotEnv) GetRuneE(key string) (rune, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: key is not set", key)
+	}
+
+	s := cast.ToString(val)
+	if s == "" {
+		return 0, fmt.Errorf("%s: value is empty", key)
+	}
+	if utf8.RuneCountInString(s) != 1 || !utf8.ValidString(s) {
+		return 0, fmt.Errorf("%s: value %q is not a single character", key, s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+// GetEnum returns the value associated with the key as a string.
+// It returns an error listing the allowed values if the value is not one of them.
+func GetEnum(key string, allowed ...string) (string, error) {
+	return GetDotEnv().GetEnum(key, allowed...)
+}
+
+func (e *DotEnv) GetEnum(key string, allowed ...string) (string, error) {
+	return e.getEnum(key, false, allowed)
+}
+
+// GetEnumFold is like GetEnum but matches the allowed values case-insensitively.
+// It returns the matching allowed value as it was provided.
+func GetEnumFold(key string, allowed ...string) (string, error) {
+	return GetDotEnv().GetEnumFold(key, allowed...)
+}
+
+func (e *DotEnv) GetEnumFold(key string, allowed ...string) (string, error) {
+	return e.getEnum(key, true, allowed)
+}
+
+func (e *DotEnv) getEnum(key string, fold bool, allowed []string) (string, error) {
+	val := e.GetString(key)
+	for _, a := range allowed {
+		if val == a || (fold && strings.EqualFold(val, a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("%s: invalid value %q, must be one of: %s", key, val, strings.Join(allowed, ", "))
+}
+
+// GetBool returns the value associated with the key as a boolean.
+func GetBool(key string) bool { return GetDotEnv().GetBool(key) }
+
+func (e *DotEnv) GetBool(key string) bool {
+	return cast.ToBool(e.Get(key))
+}
+
+// GetBoolStrict returns the value associated with the key as a boolean.
+// Unlike GetBool, it only accepts "true", "yes", "false" and "no" (case-insensitive)
+// and returns an error for any other value or if the key is not set.
+func GetBoolStrict(key string) (bool, error) { return GetDotEnv().GetBoolStrict(key) }
+
+func (e *DotEnv) GetBoolStrict(key string) (bool, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return false, fmt.Errorf("%s: key is not set", key)
+	}
+
+	switch str := strings.ToLower(strings.TrimSpace(cast.ToString(val))); str {
+	case "true", "yes":
+		return true, nil
+	case "false", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: invalid boolean value %q", key, str)
+	}
+}
+
+// GetInt returns the value associated with the key as an integer.
+func GetInt(key string) int { return GetDotEnv().GetInt(key) }
+
+func (e *DotEnv) GetInt(key string) int {
+	return cast.ToInt(e.Get(key))
+}
+
+// GetIntInRange returns the value associated with the key as an integer.
+// It returns an error if the key is not set, the value is not a valid integer
+// or the value is outside the range [min, max].
+func GetIntInRange(key string, min, max int) (int, error) {
+	return GetDotEnv().GetIntInRange(key, min, max)
+}
+
+func (e *DotEnv) GetIntInRange(key string, min, max int) (int, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: key is not set", key)
+	}
+
+	i, err := cast.ToIntE(val)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	if i < min || i > max {
+		return 0, fmt.Errorf("%s: value %d is out of range [%d, %d]", key, i, min, max)
+	}
+	return i, nil
+}
+
+// GetInt32 returns the value associated with the key as an integer.
+func GetInt32(key string) int32 { return GetDotEnv().GetInt32(key) }
+
+func (e *DotEnv) GetInt32(key string) int32 {
+	return cast.ToInt32(e.Get(key))
+}
+
+// GetInt64 returns the value associated with the key as an integer.
+func GetInt64(key string) int64 { return GetDotEnv().GetInt64(key) }
+
+func (e *DotEnv) GetInt64(key string) int64 {
+	return cast.ToInt64(e.Get(key))
+}
+
+// GetIntBase returns the value associated with the key as an integer parsed in the given base.
+// If base is 0, the base is implied by the prefix of the value:
+// 0x for hexadecimal, 0o or 0 for octal, 0b for binary and decimal otherwise.
+// It returns an error if the key is not set or the value is not a valid integer.
+func GetIntBase(key string, base int) (int64, error) { return GetDotEnv().GetIntBase(key, base) }
+
+func (e *DotEnv) GetIntBase(key string, base int) (int64, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: key is not set", key)
+	}
+
+	i, err := strconv.ParseInt(strings.TrimSpace(cast.ToString(val)), base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return i, nil
+}
+
+// GetIntStrict returns the value associated with the key as an int.
+// Unlike GetInt, it returns an error if the key is not set, the value is not a base 10 integer
+// or it's out of the range of int, e.g. 99999999999999999999, instead of silently wrapping it.
+func GetIntStrict(key string) (int, error) { return GetDotEnv().GetIntStrict(key) }
+
+func (e *DotEnv) GetIntStrict(key string) (int, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: key is not set", key)
+	}
+
+	i, err := strconv.ParseInt(strings.TrimSpace(cast.ToString(val)), 10, strconv.IntSize)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return int(i), nil
+}
+
+// GetUint returns the value associated with the key as an unsigned integer.
+func GetUint(key string) uint { return GetDotEnv().GetUint(key) }
+
+func (e *DotEnv) GetUint(key string) uint {
+	return cast.ToUint(e.Get(key))
+}
+
+// GetUint32 returns the value associated with the key as an unsigned integer.
+func GetUint32(key string) uint32 { return GetDotEnv().GetUint32(key) }
+
+func (e *DotEnv) GetUint32(key string) uint32 {
+	return cast.ToUint32(e.Get(key))
+}
+
+// GetUint64 returns the value associated with the key as an unsigned integer.
+func GetUint64(key string) uint64 { return GetDotEnv().GetUint64(key) }
+
+func (e *DotEnv) GetUint64(key string) uint64 {
+	return cast.ToUint64(e.Get(key))
+}
+
+// GetFloat64 returns the value associated with the key as a float64.
 func GetFloat64(key string) float64 { return GetDotEnv().GetFloat64(key) }
 
-func (e *DotEnv) GetFloat64(key string) float64 {
-	return cast.ToFloat64(e.Get(key))
+func (e *DotEnv) GetFloat64(key string) float64 {
+	return cast.ToFloat64(e.Get(key))
+}
+
+// GetFloat64Locale returns the value associated with the key as a float64.
+// If decimalComma is true, a comma is interpreted as the decimal separator, e.g. 3,14 is 3.14.
+// It returns an error if the key is not set or the value is not a valid number.
+func GetFloat64Locale(key string, decimalComma bool) (float64, error) {
+	return GetDotEnv().GetFloat64Locale(key, decimalComma)
+}
+
+func (e *DotEnv) GetFloat64Locale(key string, decimalComma bool) (float64, error) {
+	val, ok := e.LookUp(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: key is not set", key)
+	}
+
+	str := strings.TrimSpace(cast.ToString(val))
+	if decimalComma {
+		str = strings.Replace(str, ",", ".", 1)
+	}
+
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return f, nil
+}
+
+// GetTime returns the value associated with the key as time.
+func GetTime(key string) time.Time { return GetDotEnv().GetTime(key) }
+
+func (e *DotEnv) GetTime(key string) time.Time {
+	return cast.ToTime(e.Get(key))
+}
+
+// GetTimeUnix returns the value associated with the key as a time, parsing it as
+// a Unix timestamp in seconds, e.g. 1700000000.
+// It returns the zero time if the value is not set or is not an integer.
+func GetTimeUnix(key string) time.Time { return GetDotEnv().GetTimeUnix(key) }
+
+func (e *DotEnv) GetTimeUnix(key string) time.Time {
+	sec, ok := e.getUnix(key)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// GetTimeUnixMilli is like GetTimeUnix but parses the value as a Unix timestamp in milliseconds,
+// e.g. 1700000000000.
+func GetTimeUnixMilli(key string) time.Time { return GetDotEnv().GetTimeUnixMilli(key) }
+
+func (e *DotEnv) GetTimeUnixMilli(key string) time.Time {
+	msec, ok := e.getUnix(key)
+	if !ok {
+		return time.Time{}
+	}
+	return time.UnixMilli(msec)
+}
+
+// getUnix parses the value associated with the key as a base 10 integer.
+func (e *DotEnv) getUnix(key string) (int64, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(e.GetString(key)), 10, 64)
+	return n, err == nil
+}
+
+// GetDuration returns the value associated with the key as a duration.
+func GetDuration(key string) time.Duration { return GetDotEnv().GetDuration(key) }
+
+func (e *DotEnv) GetDuration(key string) time.Duration {
+	return cast.ToDuration(e.Get(key))
+}
+
+// GetDurationSlice returns the value associated with the key as a slice of durations,
+// e.g. "100ms,1s,5s". Invalid elements are converted to 0, use GetDurationSliceE to detect them.
+func GetDurationSlice(key string) []time.Duration { return GetDotEnv().GetDurationSlice(key) }
+
+func (e *DotEnv) GetDurationSlice(key string) []time.Duration {
+	values := splitWithSep(e.GetString(key), ",")
+	durations := make([]time.Duration, len(values))
+	for i, val := range values {
+		durations[i] = cast.ToDuration(val)
+	}
+	return durations
+}
+
+// GetDurationSliceE is like GetDurationSlice but returns an error if an element is not a valid duration.
+func GetDurationSliceE(key string) ([]time.Duration, error) {
+	return GetDotEnv().GetDurationSliceE(key)
+}
+
+func (e *DotEnv) GetDurationSliceE(key string) ([]time.Duration, error) {
+	values := splitWithSep(e.GetString(key), ",")
+	durations := make([]time.Duration, len(values))
+	for i, val := range values {
+		d, err := cast.ToDurationE(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid duration %q at index %d", key, val, i)
+		}
+		durations[i] = d
+	}
+	return durations, nil
+}
+
+// GetIntSlice returns the value associated with the key as a slice of int values.
+func GetIntSlice(key string) []int { return GetDotEnv().GetIntSlice(key) }
+
+func (e *DotEnv) GetIntSlice(key string) []int {
+	return cast.ToIntSlice(toSlice(e.GetString(key)))
+}
+
+// toSlice splits a comma-separated value into a slice.
+// Surrounding brackets are only stripped when they are matched,
+// e.g. "[a,b]", so values that merely contain a bracket are left intact.
+// Quoted elements are unquoted, see unquoteElems.
+func toSlice(value string) []string {
+	if len(value) > 1 && value[0] == '[' && value[len(value)-1] == ']' {
+		value = value[1 : len(value)-1]
+	}
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+	return unquoteElems(strings.Split(value, ","))
+}
+
+// unquoteElems removes the quotes of the elements that are wrapped in matching
+// single or double quotes, e.g. 'a' becomes a. Other elements are left intact.
+func unquoteElems(elems []string) []string {
+	for i, elem := range elems {
+		trimmed := strings.TrimSpace(elem)
+		if len(trimmed) > 1 && trimmed[0] == trimmed[len(trimmed)-1] &&
+			(trimmed[0] == prefixSingleQuote || trimmed[0] == prefixDoubleQuote) {
+			elems[i] = trimmed[1 : len(trimmed)-1]
+		}
+	}
+	return elems
+}
+
+// GetStringSlice returns the value associated with the key as a slice of strings.
+// If the value is a JSON array of strings, e.g. ["a","b"], it's decoded as JSON.
+// If the value is a list of double-quoted elements, e.g. "a","b,c", the elements
+// can contain commas and quotes escaped as \" or "".
+// Otherwise, the value is split on commas, preserving empty elements.
+// An empty value returns an empty slice.
+func GetStringSlice(key string) []string { return GetDotEnv().GetStringSlice(key) }
+
+func (e *DotEnv) GetStringSlice(key string) []string {
+	value := e.GetString(key)
+	trimmed := strings.TrimSpace(value)
+	if elems, ok := parseJSONArray(trimmed); ok {
+		return elems
+	}
+	if elems, ok := splitQuoted(trimmed); ok {
+		return elems
+	}
+	return cast.ToStringSlice(toSlice(value))
+}
+
+// GetStringSliceCompact is like GetStringSlice but drops the empty and whitespace-only elements,
+// e.g. a,,b, returns ["a" "b"] instead of ["a" "" "b" ""].
+func GetStringSliceCompact(key string) []string { return GetDotEnv().GetStringSliceCompact(key) }
+
+func (e *DotEnv) GetStringSliceCompact(key string) []string {
+	elems := e.GetStringSlice(key)
+	compact := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if strings.TrimSpace(elem) != "" {
+			compact = append(compact, elem)
+		}
+	}
+	return compact
+}
+
+// GetStringSliceN is like GetStringSlice but returns an error if the value has more than max elements,
+// to guard against misconfigured values with a huge number of elements.
+func GetStringSliceN(key string, max int) ([]string, error) {
+	return GetDotEnv().GetStringSliceN(key, max)
+}
+
+func (e *DotEnv) GetStringSliceN(key string, max int) ([]string, error) {
+	elems := e.GetStringSlice(key)
+	if len(elems) > max {
+		return nil, fmt.Errorf("%s: %d elements exceed the maximum of %d", key, len(elems), max)
+	}
+	return elems, nil
+}
+
+// GetStringSliceUnique is like GetStringSlice but removes the duplicate elements,
+// keeping the first occurrence of each, e.g. a,b,a,c returns ["a" "b" "c"].
+func GetStringSliceUnique(key string) []string { return GetDotEnv().GetStringSliceUnique(key) }
+
+func (e *DotEnv) GetStringSliceUnique(key string) []string {
+	elems := e.GetStringSlice(key)
+	seen := make(map[string]bool, len(elems))
+	unique := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if !seen[elem] {
+			seen[elem] = true
+			unique = append(unique, elem)
+		}
+	}
+	return unique
+}
+
+// parseJSONArray decodes a JSON array of strings.
+// It reports false if value is not a valid JSON array of strings.
+func parseJSONArray(value string) ([]string, bool) {
+	if len(value) < 2 || value[0] != '[' || value[len(value)-1] != ']' {
+		return nil, false
+	}
+
+	var elems []string
+	if err := json.Unmarshal([]byte(value), &elems); err != nil {
+		return nil, false
+	}
+	if elems == nil {
+		elems = []string{}
+	}
+	return elems, true
+}
+
+// splitQuoted splits a comma-separated list of double-quoted elements, e.g. "a","b,c".
+// It reports false if value is not such a list.
+func splitQuoted(value string) ([]string, bool) {
+	if value == "" || value[0] != prefixDoubleQuote {
+		return nil, false
+	}
+
+	var elems []string
+	for i := 0; i < len(value); {
+		if value[i] != prefixDoubleQuote {
+			return nil, false
+		}
+
+		// read the element up to its closing quote
+		var b strings.Builder
+		i++
+		closed := false
+		for i < len(value) && !closed {
+			switch c := value[i]; {
+			case (c == '\\' || c == prefixDoubleQuote) && i+1 < len(value) && value[i+1] == prefixDoubleQuote:
+				// escaped quote, \" or ""
+				b.WriteByte(prefixDoubleQuote)
+				i += 2
+			case c == prefixDoubleQuote:
+				closed = true
+				i++
+			default:
+				b.WriteByte(c)
+				i++
+			}
+		}
+		if !closed {
+			return nil, false
+		}
+		elems = append(elems, b.String())
+
+		// skip the whitespace and comma before the next element
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) {
+			break
+		}
+		if value[i] != ',' {
+			return nil, false
+		}
+		i++
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) {
+			return nil, false
+		}
+	}
+	return elems, true
+}
+
+// GetStringSliceWithSep returns the value associated with the key as a slice of strings
+// split on sep instead of a comma. Each element is trimmed of surrounding whitespace
+// and empty trailing elements are dropped.
+func GetStringSliceWithSep(key, sep string) []string {
+	return GetDotEnv().GetStringSliceWithSep(key, sep)
+}
+
+func (e *DotEnv) GetStringSliceWithSep(key, sep string) []string {
+	return cast.ToStringSlice(unquoteElems(splitWithSep(e.GetString(key), sep)))
+}
+
+// GetIntSliceWithSep returns the value associated with the key as a slice of int values
+// split on sep instead of a comma.
+func GetIntSliceWithSep(key, sep string) []int { return GetDotEnv().GetIntSliceWithSep(key, sep) }
+
+func (e *DotEnv) GetIntSliceWithSep(key, sep string) []int {
+	return cast.ToIntSlice(unquoteElems(splitWithSep(e.GetString(key), sep)))
+}
+
+// GetLines returns the value associated with the key as a slice of its lines.
+// Each line is trimmed of surrounding whitespace and blank lines are dropped.
+// This is useful for multi-line quoted values.
+func GetLines(key string) []string { return GetDotEnv().GetLines(key) }
+
+func (e *DotEnv) GetLines(key string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(e.GetString(key), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// GetArgs returns the value associated with the key split into words like a POSIX shell does,
+// e.g. --foo "bar baz" --qux returns ["--foo" "bar baz" "--qux"], which is handy for passing
+// configured arguments to exec.Command. Single quotes preserve their contents literally,
+// while a backslash escapes the next character outside quotes and ", \, $ and ` in double quotes.
+// Variables and globs are not expanded. An unset or empty value returns an empty slice.
+func GetArgs(key string) ([]string, error) { return GetDotEnv().GetArgs(key) }
+
+func (e *DotEnv) GetArgs(key string) ([]string, error) {
+	args, err := splitArgs(e.GetString(key))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return args, nil
+}
+
+// splitArgs splits value into shell words.
+func splitArgs(value string) ([]string, error) {
+	args := []string{}
+	var (
+		word   strings.Builder
+		inWord bool
+		quote  rune
+	)
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, errors.New("unterminated escape sequence")
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// GetIndexedSlice returns the values of the indexed keys PREFIX_0, PREFIX_1, ..., PREFIX_N
+// in order, stopping at the first missing index.
+func GetIndexedSlice(prefix string) []string { return GetDotEnv().GetIndexedSlice(prefix) }
+
+func (e *DotEnv) GetIndexedSlice(prefix string) []string {
+	prefix = strings.TrimSuffix(prefix, "_")
+	values := []string{}
+	for i := 0; ; i++ {
+		val, ok := e.LookUp(prefix + "_" + strconv.Itoa(i))
+		if !ok {
+			return values
+		}
+		values = append(values, cast.ToString(val))
+	}
+}
+
+// GetStringMapStringSlice returns the value associated with the key as a map of string slices.
+// Groups are separated by a semicolon, a group's key from its list by a colon
+// and the list elements by a comma, e.g. "web:80,443;api:8080".
+func GetStringMapStringSlice(key string) map[string][]string {
+	return GetDotEnv().GetStringMapStringSlice(key)
+}
+
+func (e *DotEnv) GetStringMapStringSlice(key string) map[string][]string {
+	return e.GetStringMapStringSliceWithSep(key, ";", ":", ",")
+}
+
+// GetStringMapStringSliceWithSep is like GetStringMapStringSlice but uses the provided
+// group, key/value and list separators.
+func GetStringMapStringSliceWithSep(key, groupSep, kvSep, listSep string) map[string][]string {
+	return GetDotEnv().GetStringMapStringSliceWithSep(key, groupSep, kvSep, listSep)
+}
+
+func (e *DotEnv) GetStringMapStringSliceWithSep(key, groupSep, kvSep, listSep string) map[string][]string {
+	m := make(map[string][]string)
+	for _, group := range splitWithSep(e.GetString(key), groupSep) {
+		if group == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(group, kvSep)
+		m[strings.TrimSpace(k)] = splitWithSep(v, listSep)
+	}
+	return m
+}
+
+// GetStringMapString returns the value associated with the key as a map of strings.
+// Entries are separated by a comma and an entry's key from its value by a colon,
+// e.g. "env:prod,region:eu". Entries without a colon are skipped, use GetStringMapStringE to detect them.
+// A map stored with Set is returned as is.
+func GetStringMapString(key string) map[string]string { return GetDotEnv().GetStringMapString(key) }
+
+func (e *DotEnv) GetStringMapString(key string) map[string]string {
+	m, _ := e.getStringMap(key)
+	return m
+}
+
+// GetStringMapStringE is like GetStringMapString but returns an error if an entry has no colon.
+func GetStringMapStringE(key string) (map[string]string, error) {
+	return GetDotEnv().GetStringMapStringE(key)
+}
+
+func (e *DotEnv) GetStringMapStringE(key string) (map[string]string, error) {
+	m, err := e.getStringMap(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return m, nil
+}
+
+// GetStringMapInt returns the value associated with the key as a map of int values,
+// e.g. "a:1,b:2". Invalid entries are skipped, use GetStringMapIntE to detect them.
+func GetStringMapInt(key string) map[string]int { return GetDotEnv().GetStringMapInt(key) }
+
+func (e *DotEnv) GetStringMapInt(key string) map[string]int {
+	strs, _ := e.getStringMap(key)
+	m := make(map[string]int, len(strs))
+	for k, v := range strs {
+		if i, err := cast.ToIntE(v); err == nil {
+			m[k] = i
+		}
+	}
+	return m
+}
+
+// GetStringMapIntE is like GetStringMapInt but returns an error for invalid entries.
+func GetStringMapIntE(key string) (map[string]int, error) { return GetDotEnv().GetStringMapIntE(key) }
+
+func (e *DotEnv) GetStringMapIntE(key string) (map[string]int, error) {
+	strs, err := e.getStringMap(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	m := make(map[string]int, len(strs))
+	for k, v := range strs {
+		if m[k], err = cast.ToIntE(v); err != nil {
+			return nil, fmt.Errorf("%s: invalid map value for %q: %w", key, k, err)
+		}
+	}
+	return m, nil
+}
+
+// GetStringMapBool returns the value associated with the key as a map of bool values,
+// e.g. "beta:true,legacy:false". Invalid entries are skipped, use GetStringMapBoolE to detect them.
+func GetStringMapBool(key string) map[string]bool { return GetDotEnv().GetStringMapBool(key) }
+
+func (e *DotEnv) GetStringMapBool(key string) map[string]bool {
+	strs, _ := e.getStringMap(key)
+	m := make(map[string]bool, len(strs))
+	for k, v := range strs {
+		if b, err := cast.ToBoolE(v); err == nil {
+			m[k] = b
+		}
+	}
+	return m
+}
+
+// GetStringMapBoolE is like GetStringMapBool but returns an error for invalid entries.
+func GetStringMapBoolE(key string) (map[string]bool, error) {
+	return GetDotEnv().GetStringMapBoolE(key)
+}
+
+func (e *DotEnv) GetStringMapBoolE(key string) (map[string]bool, error) {
+	strs, err := e.getStringMap(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	m := make(map[string]bool, len(strs))
+	for k, v := range strs {
+		if m[k], err = cast.ToBoolE(v); err != nil {
+			return nil, fmt.Errorf("%s: invalid map value for %q: %w", key, k, err)
+		}
+	}
+	return m, nil
+}
+
+// getStringMap returns the value associated with the key as a map of strings.
+// Maps stored with Set are returned as is, other values are parsed with parseStringMap.
+func (e *DotEnv) getStringMap(key string) (map[string]string, error) {
+	switch val := e.Get(key).(type) {
+	case map[string]string:
+		return maps.Clone(val), nil
+	case map[string]any:
+		return cast.ToStringMapStringE(val)
+	default:
+		return parseStringMap(cast.ToString(val))
+	}
+}
+
+// parseStringMap parses a k:v,k:v value into a map.
+// Entries without a colon are skipped and reported with an error.
+func parseStringMap(value string) (map[string]string, error) {
+	var err error
+	m := make(map[string]string)
+	for _, pair := range splitWithSep(value, ",") {
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, ":")
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("invalid map entry %q: missing separator ':'", pair)
+			}
+			continue
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m, err
+}
+
+// splitWithSep splits value on sep, trimming each element and
+// dropping empty trailing elements.
+func splitWithSep(value, sep string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(value, sep)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+// GetSizeInBytes returns the size of the value associated with the given key
+// in bytes.
+func GetSizeInBytes(key string) uint { return GetDotEnv().GetSizeInBytes(key) }
+
+func (e *DotEnv) GetSizeInBytes(key string) uint {
+	sizeStr := cast.ToString(e.Get(key))
+	return parseSizeInBytes(sizeStr)
+}
+
+// IsSet checks to see if the key has been set in any of the env var, config cache or config file.
+// IsSet is case-insensitive for a key.
+func IsSet(key string) bool { return GetDotEnv().IsSet(key) }
+
+func (e *DotEnv) IsSet(key string) bool {
+	if key == "" || e.parent != nil || e.preserveKeyCase {
+		_, set := e.LookUp(key)
+		return set
+	}
+
+	// fast path of LookUp that doesn't box the value and only holds a read lock
+	key = e.normalizeKey(key)
+	if _, ok := e.lookupTTL(key); ok {
+		e.lookupsCache.Add(1)
+		return true
+	}
+	if _, ok := e.lookupEnv(key); ok {
+		e.lookupsEnv.Add(1)
+		return true
+	}
+
+	e.mu.RLock()
+	_, ok := e.cachedConfig[key]
+	if !ok {
+		_, ok = e.defaults[key]
+	}
+	e.mu.RUnlock()
+
+	if ok {
+		e.lookupsCache.Add(1)
+	} else {
+		e.lookupMisses.Add(1)
+	}
+	return ok
+}
+
+// State reports whether the key is present, like IsSet, and whether its value is empty,
+// to tell apart a key that is set to an empty string from an unset key in one call.
+// empty is always false if the key is not present.
+func State(key string) (present, empty bool) { return GetDotEnv().State(key) }
+
+func (e *DotEnv) State(key string) (present, empty bool) {
+	val, present := e.LookUp(key)
+	if !present {
+		return false, false
+	}
+	return true, cast.ToString(val) == ""
+}
+
+// Keys returns the sorted keys in the config cache.
+// The keys of a view created with WithOverrides include the keys of its parent.
+func Keys() []string { return GetDotEnv().Keys() }
+
+func (e *DotEnv) Keys() []string {
+	config := e.configSnapshot()
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// configSnapshot returns a copy of the config cache.
+// The config of a view is the config of its parent overlaid with its overrides.
+func (e *DotEnv) configSnapshot() map[string]any {
+	var config map[string]any
+	if e.parent != nil {
+		config = e.parent.configSnapshot()
+	}
+
+	e.mu.RLock()
+	if config == nil {
+		config = make(map[string]any, len(e.cachedConfig))
+	}
+	for key, value := range e.cachedConfig {
+		config[key] = value
+	}
+	e.mu.RUnlock()
+	return config
+}
+
+// AllKeys returns the sorted keys in the config cache and of the environment variables
+// under the configured prefix. The prefix is stripped from the keys and duplicates are removed.
+func AllKeys() []string { return GetDotEnv().AllKeys() }
+
+func (e *DotEnv) AllKeys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(key string) {
+		key = strings.TrimPrefix(key, e.prefix)
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range e.Keys() {
+		add(key)
+	}
+
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if e.prefix != "" && !strings.HasPrefix(key, e.prefix) {
+			continue
+		}
+		add(key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// RequireKeys checks that all the keys are set in any of the env var, config cache or config file.
+// It returns an error naming every missing key.
+func RequireKeys(keys ...string) error { return GetDotEnv().RequireKeys(keys...) }
+
+func (e *DotEnv) RequireKeys(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if !e.IsSet(key) {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required keys: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// LookUp retrieves the value of the configuration named by the key.
+// If the variable is set (which may be empty) is returned and the boolean is true.
+// Otherwise the returned value will be empty and the boolean will be false.
+func LookUp(key string) (any, bool) { return GetDotEnv().LookUp(key) }
+
+func (e *DotEnv) LookUp(key string) (any, bool) {
+	return e.lookupPrefixed(e.prefix, key)
+}
+
+// lookupPrefixed is like LookUp but uses the given prefix instead of the configured one.
+func (e *DotEnv) lookupPrefixed(prefix, key string) (any, bool) {
+	val, _, ok := e.lookupSource(prefix, key)
+	return val, ok
+}
+
+// The sources of the values reported by lookupSource and the UnmarshalDebug callback.
+const (
+	sourceEnv     = "env"
+	sourceCache   = "cache"
+	sourceDefault = "default"
+	sourceSkipped = "skipped"
+)
+
+// lookupSource is like lookupPrefixed but also returns where the value was found:
+// sourceEnv, sourceCache or sourceDefault for the defaults set with SetDefault.
+func (e *DotEnv) lookupSource(prefix, key string) (any, string, bool) {
+	if e.parent != nil {
+		return e.lookupOverride(prefix, key)
+	}
+
+	if key != "" {
+		key = e.normalizePrefixedKey(prefix, key)
+
+		if val, ok := e.lookupTTL(key); ok {
+			e.lookupsCache.Add(1)
+			return val, sourceCache, true
+		}
+
+		if val, ok := e.lookupEnv(key); ok {
+			e.lookupsEnv.Add(1)
+			return val, sourceEnv, true
+		}
+		if e.preserveKeyCase {
+			if val, ok := e.lookupEnv(strings.ToUpper(key)); ok {
+				e.lookupsEnv.Add(1)
+				return val, sourceEnv, true
+			}
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if cachedEnv, okEnv := e.cachedConfig[key]; okEnv {
+			e.lookupsCache.Add(1)
+			return cachedEnv, sourceCache, true
+		}
+
+		if def, okDef := e.defaults[key]; okDef {
+			e.lookupsCache.Add(1)
+			return def, sourceDefault, true
+		}
+
+		if e.preserveKeyCase {
+			if val, source, ok := e.lookupFold(key); ok {
+				e.lookupsCache.Add(1)
+				return val, source, true
+			}
+		}
+	}
+	e.lookupMisses.Add(1)
+	return nil, "", false
+}
+
+// WithOverrides returns a view of the global DotEnv instance whose values are overlaid with m,
+// see DotEnv.WithOverrides.
+func WithOverrides(m map[string]any) *DotEnv { return GetDotEnv().WithOverrides(m) }
+
+// WithOverrides returns a lightweight view of e whose getters return the values in m first
+// and fall through to e for the other keys, e.g. for request-scoped values.
+// The overrides take precedence over environment variables.
+// The view uses the type converters, set middlewares and decoders registered on e,
+// and the PreserveKeyCase and AllowIncludes settings of e.
+// The config cache of e is not copied, and calling Set on the view only changes its overrides,
+// so e is never modified by the view.
+func (e *DotEnv) WithOverrides(m map[string]any) *DotEnv {
+	view := &DotEnv{
+		decoder:           e.decoder,
+		encoder:           e.encoder,
+		fileReader:        e.fileReader,
+		configFile:        e.configFile,
+		prefix:            e.prefix,
+		allowEmptyEnvVars: e.allowEmptyEnvVars,
+		panicOnSetError:   e.panicOnSetError,
+		normalizeKeys:     e.normalizeKeys,
+		keyPolicy:         e.keyPolicy,
+		envKeyReplacer:    e.envKeyReplacer,
+		validator:         e.validator,
+		unmarshalDebug:    e.unmarshalDebug,
+		filePerm:          e.filePerm,
+		cachedConfig:      make(map[string]any, len(m)),
+		defaults:          make(map[string]any),
+		parent:            e,
+	}
+	for key, value := range m {
+		view.cachedConfig[view.normalizeKey(key)] = value
+	}
+	return view
+}
+
+// root returns the instance a view was created from, or e itself if it's not a view.
+func (e *DotEnv) root() *DotEnv {
+	for e.parent != nil {
+		e = e.parent
+	}
+	return e
+}
+
+// contextKey is the key of the DotEnv instance stored in a context by ToContext.
+type contextKey struct{}
+
+// ToContext returns a copy of ctx carrying e, e.g. a request-scoped view created with WithOverrides
+// that handlers retrieve with FromContext.
+func ToContext(ctx context.Context, e *DotEnv) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the DotEnv instance stored in ctx by ToContext,
+// or the global DotEnv instance if there's none.
+func FromContext(ctx context.Context) *DotEnv {
+	if e, ok := ctx.Value(contextKey{}).(*DotEnv); ok && e != nil {
+		return e
+	}
+	return GetDotEnv()
+}
+
+// lookupOverride looks up the key under prefix in the overrides of a view, falling through to its parent.
+func (e *DotEnv) lookupOverride(prefix, key string) (any, string, bool) {
+	if key != "" {
+		e.mu.RLock()
+		val, ok := e.cachedConfig[e.normalizePrefixedKey(prefix, key)]
+		e.mu.RUnlock()
+		if ok {
+			e.lookupsCache.Add(1)
+			return val, sourceCache, true
+		}
+	}
+	return e.parent.lookupSource(prefix, key)
+}
+
+// GetStats returns the usage counters of the global DotEnv instance.
+func GetStats() Stats { return GetDotEnv().Stats() }
+
+// Stats returns the usage counters of the DotEnv instance.
+func (e *DotEnv) Stats() Stats {
+	return Stats{
+		Loads:        e.loads.Load(),
+		LookupsEnv:   e.lookupsEnv.Load(),
+		LookupsCache: e.lookupsCache.Load(),
+		LookupMisses: e.lookupMisses.Load(),
+	}
+}
+
+// RawValue returns the value of the key as written in the config file,
+// before quotes are removed and escape sequences are processed.
+// This requires a decoder that retains raw values, e.g. DefaultDecoder with KeepRawValues enabled.
+func RawValue(key string) (string, bool) { return GetDotEnv().RawValue(key) }
+
+func (e *DotEnv) RawValue(key string) (string, bool) {
+	d, ok := e.decoder.(interface {
+		RawValue(key string) (string, bool)
+	})
+	if !ok || key == "" {
+		return "", false
+	}
+	return d.RawValue(e.normalizeKey(key))
+}
+
+// lookupEnv retrieves the value of the environment variable named by the normalized key.
+func (e *DotEnv) lookupEnv(key string) (string, bool) {
+	if e.envKeyReplacer != nil {
+		key = e.envKeyReplacer.Replace(key)
+	}
+	if val, ok := os.LookupEnv(key); ok {
+		if val != "" && !e.allowEmptyEnvVars {
+			return val, true
+		}
+	}
+	return "", false
 }
 
-// GetTime returns the value associated with the key as time.
-func GetTime(key string) time.Time { return GetDotEnv().GetTime(key) }
+// GetByPattern returns all the values whose keys match the glob pattern
+// as supported by path.Match, e.g. "FEATURE_*".
+// Both the config cache and environment variables under the configured prefix are matched,
+// with environment variables taking precedence.
+func GetByPattern(glob string) map[string]any { return GetDotEnv().GetByPattern(glob) }
+
+func (e *DotEnv) GetByPattern(glob string) map[string]any {
+	if e.parent != nil {
+		// the overrides of a view take precedence over environment variables
+		values := e.parent.GetByPattern(glob)
+		glob = e.normalizeKey(glob)
+		e.mu.RLock()
+		for key, value := range e.cachedConfig {
+			if matched, _ := path.Match(glob, key); matched {
+				values[key] = value
+			}
+		}
+		e.mu.RUnlock()
+		return values
+	}
 
-func (e *DotEnv) GetTime(key string) time.Time {
-	return cast.ToTime(e.Get(key))
+	glob = e.normalizeKey(glob)
+	values := make(map[string]any)
+
+	e.mu.RLock()
+	for key, value := range e.cachedConfig {
+		if matched, _ := path.Match(glob, key); matched {
+			values[key] = value
+		}
+	}
+	e.mu.RUnlock()
+
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if e.prefix != "" && !strings.HasPrefix(key, e.prefix) {
+			continue
+		}
+		if matched, _ := path.Match(glob, key); !matched {
+			continue
+		}
+		if val, ok := e.lookupEnv(key); ok {
+			values[key] = val
+		}
+	}
+
+	return values
 }
 
-// GetDuration returns the value associated with the key as a duration.
-func GetDuration(key string) time.Duration { return GetDotEnv().GetDuration(key) }
+// GetMapFromPrefix returns the values of the keys starting with prefix, e.g. FEATURE_,
+// keyed by the rest of the key: FEATURE_A=1 and FEATURE_B=2 return {"A": "1", "B": "2"}.
+// Keys in the config cache, defaults and environment variables are collected,
+// with values resolved as by Get.
+func GetMapFromPrefix(prefix string) map[string]string { return GetDotEnv().GetMapFromPrefix(prefix) }
+
+func (e *DotEnv) GetMapFromPrefix(prefix string) map[string]string {
+	prefix = e.normalizeKey(prefix)
+	values := make(map[string]string)
+	for _, key := range e.keysWithPrefix(prefix) {
+		name := strings.TrimPrefix(key, prefix)
+		if name == "" {
+			continue
+		}
+		if val, ok := e.lookupPrefixed("", key); ok {
+			values[name] = cast.ToString(val)
+		}
+	}
+	return values
+}
 
-func (e *DotEnv) GetDuration(key string) time.Duration {
-	return cast.ToDuration(e.Get(key))
+// redactPatterns are the glob patterns of keys whose values are masked by String.
+var redactPatterns = []string{"*PASSWORD*", "*SECRET*", "*TOKEN*", "*PRIVATE*", "*_KEY"}
+
+const redactedValue = "******"
+
+func isRedacted(key string) bool {
+	for _, pattern := range redactPatterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
 }
 
-// GetIntSlice returns the value associated with the key as a slice of int values.
-func GetIntSlice(key string) []int { return GetDotEnv().GetIntSlice(key) }
+// resolvedConfig returns the values of the keys in the config cache as strings,
+// with environment variables taking precedence.
+// The values of a view are those of its parent overlaid with its overrides.
+func (e *DotEnv) resolvedConfig() map[string]string {
+	if e.parent != nil {
+		values := e.parent.resolvedConfig()
+		e.mu.RLock()
+		for key, value := range e.cachedConfig {
+			values[key] = cast.ToString(value)
+		}
+		e.mu.RUnlock()
+		return values
+	}
 
-func (e *DotEnv) GetIntSlice(key string) []int {
-	return cast.ToIntSlice(toSlice(e.GetString(key)))
+	e.mu.RLock()
+	values := make(map[string]string, len(e.cachedConfig))
+	for key, value := range e.cachedConfig {
+		values[key] = cast.ToString(value)
+	}
+	e.mu.RUnlock()
+
+	for key := range values {
+		if val, ok := e.lookupEnv(key); ok {
+			values[key] = val
+		}
+	}
+	return values
 }
-func toSlice(value string) []string {
-	value = strings.TrimPrefix(value, "[")
-	value = strings.TrimSuffix(value, "]")
-	return strings.Split(value, ",")
+
+// Equal reports whether e and other have the same keys in their config cache
+// with the same resolved values, compared as strings.
+// Environment variables take precedence over the cached values as with Get,
+// while the decoder and other settings are ignored.
+func (e *DotEnv) Equal(other *DotEnv) bool {
+	if other == nil {
+		return false
+	}
+
+	a, b := e.resolvedConfig(), other.resolvedConfig()
+	if len(a) != len(b) {
+		return false
+	}
+	for key, val := range a {
+		if otherVal, ok := b[key]; !ok || otherVal != val {
+			return false
+		}
+	}
+	return true
 }
 
-// GetStringSlice returns the value associated with the key as a slice of strings.
-func GetStringSlice(key string) []string { return GetDotEnv().GetStringSlice(key) }
+// String returns the resolved configuration as sorted KEY=value lines.
+// Values of keys that look like secrets, e.g. DB_PASSWORD or API_KEY, are masked.
+func (e *DotEnv) String() string {
+	values := e.resolvedConfig()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-func (e *DotEnv) GetStringSlice(key string) []string {
-	return cast.ToStringSlice(toSlice(e.GetString(key)))
+	var b strings.Builder
+	for _, key := range keys {
+		value := values[key]
+		if value != "" && isRedacted(key) {
+			value = redactedValue
+		}
+		b.WriteString(key + "=" + encodeValue(value, false) + "\n")
+	}
+
+	return b.String()
 }
 
-// GetSizeInBytes returns the size of the value associated with the given key
-// in bytes.
-func GetSizeInBytes(key string) uint { return GetDotEnv().GetSizeInBytes(key) }
+// Set sets or update env variable
+// This will be used instead of following the normal precedence
+// when getting the value.
+// Set panics if the config is frozen.
+func Set(key string, value any) { GetDotEnv().Set(key, value) }
 
-func (e *DotEnv) GetSizeInBytes(key string) uint {
-	sizeStr := cast.ToString(e.Get(key))
-	return parseSizeInBytes(sizeStr)
+func (e *DotEnv) Set(key string, value any) {
+	if err := e.SetE(key, value); err != nil && (e.panicOnSetError || errors.Is(err, ErrFrozen)) {
+		panic(err)
+	}
 }
 
-// IsSet checks to see if the key has been set in any of the env var, config cache or config file.
-// IsSet is case-insensitive for a key.
-func IsSet(key string) bool { return GetDotEnv().IsSet(key) }
+// SetE is like Set but returns the error from any registered SetMiddleware,
+// or ErrFrozen if the config is frozen.
+// The value is not stored if a middleware rejects it.
+func SetE(key string, value any) error { return GetDotEnv().SetE(key, value) }
 
-func (e *DotEnv) IsSet(key string) bool {
-	_, set := e.LookUp(key)
-	return set
+func (e *DotEnv) SetE(key string, value any) error {
+	if e.isFrozen() {
+		return ErrFrozen
+	}
+
+	key = e.normalizeKey(key)
+
+	for _, m := range e.setMiddlewares() {
+		var err error
+		value, err = m(key, value)
+		if err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+
+	e.mu.Lock()
+	e.cachedConfig[e.cacheKey(key)] = value
+	e.mu.Unlock()
+
+	return nil
 }
 
-// LookUp retrieves the value of the configuration named by the key.
-// If the variable is set (which may be empty) is returned and the boolean is true.
-// Otherwise the returned value will be empty and the boolean will be false.
-func LookUp(key string) (any, bool) { return GetDotEnv().LookUp(key) }
+// setMiddlewares returns the middlewares run by SetE.
+// The middlewares of the parent of a view run before those of the view.
+func (e *DotEnv) setMiddlewares() []SetMiddleware {
+	var middleware []SetMiddleware
+	if e.parent != nil {
+		middleware = e.parent.setMiddlewares()
+	}
 
-func (e *DotEnv) LookUp(key string) (any, bool) {
-	if key != "" {
-		key = strings.ToUpper(e.addPrefix(key))
+	e.mu.RLock()
+	middleware = append(middleware, e.setMiddleware...)
+	e.mu.RUnlock()
+	return middleware
+}
 
-		if val, ok := os.LookupEnv(key); ok {
-			if val != "" && !e.allowEmptyEnvVars {
-				return val, true
-			}
+// cacheKey returns the key the value of key is stored under in the config cache.
+// If PreserveKeyCase is enabled, the spelling of an existing key that matches ignoring case is kept,
+// so the same key is never stored twice. The caller must hold mu.
+func (e *DotEnv) cacheKey(key string) string {
+	if e.root().preserveKeyCase {
+		return e.existingKeyFold(key)
+	}
+	return key
+}
+
+// existingKeyFold returns the key of the config cache that matches key ignoring case,
+// or key itself if there's none. The caller must hold mu.
+func (e *DotEnv) existingKeyFold(key string) string {
+	if _, ok := e.cachedConfig[key]; ok {
+		return key
+	}
+	for k := range e.cachedConfig {
+		if strings.EqualFold(k, key) {
+			return k
 		}
+	}
+	return key
+}
 
-		e.mu.Lock()
-		defer e.mu.Unlock()
+// ttlValue is a value set with SetWithTTL and the time it expires.
+type ttlValue struct {
+	value   any
+	expires time.Time
+}
 
-		if cachedEnv, okEnv := e.cachedConfig[key]; okEnv {
-			return cachedEnv, true
+// SetWithTTL sets a temporary override of the key that expires after ttl,
+// e.g. to flip a feature flag during an incident.
+// Until it expires, the override takes precedence over environment variables and the config cache.
+// After that, Get returns the underlying value again. Expired overrides are removed lazily on lookup.
+// SetWithTTL panics if the config is frozen.
+func SetWithTTL(key string, value any, ttl time.Duration) { GetDotEnv().SetWithTTL(key, value, ttl) }
+
+func (e *DotEnv) SetWithTTL(key string, value any, ttl time.Duration) {
+	if e.isFrozen() {
+		panic(ErrFrozen)
+	}
+
+	key = e.normalizeKey(key)
+
+	e.mu.Lock()
+	if e.ttlOverrides == nil {
+		e.ttlOverrides = make(map[string]ttlValue)
+	}
+	e.ttlOverrides[key] = ttlValue{value: value, expires: time.Now().Add(ttl)}
+	e.hasTTL.Store(true)
+	e.mu.Unlock()
+}
+
+// lookupTTL returns the value of an override set with SetWithTTL that has not expired yet.
+func (e *DotEnv) lookupTTL(key string) (any, bool) {
+	if !e.hasTTL.Load() {
+		return nil, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	override, ok := e.ttlOverrides[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(override.expires) {
+		delete(e.ttlOverrides, key)
+		return nil, false
+	}
+	return override.value, true
+}
+
+// Merge copies the config cache of other into e.
+// Keys that are already in e are only replaced if overwrite is true.
+// Merge panics if e is frozen.
+func Merge(other *DotEnv, overwrite bool) { GetDotEnv().Merge(other, overwrite) }
+
+func (e *DotEnv) Merge(other *DotEnv, overwrite bool) {
+	if e.isFrozen() {
+		panic(ErrFrozen)
+	}
+	if other == nil || other == e {
+		return
+	}
+
+	// copy the values of other first so both instances are never locked at once,
+	// which could deadlock when two instances are merged into each other concurrently
+	values := other.configSnapshot()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, val := range values {
+		key = e.cacheKey(key)
+		if _, ok := e.cachedConfig[key]; ok && !overwrite {
+			continue
 		}
+		e.cachedConfig[key] = val
 	}
-	return nil, false
 }
 
-// Set sets or update env variable
-// This will be used instead of following the normal precedence
-// when getting the value
-func Set(key string, value any) { GetDotEnv().Set(key, value) }
+// mergeConfig copies the loaded config into the config cache. The caller must hold mu.
+func (e *DotEnv) mergeConfig(config map[string]any) {
+	for key, val := range config {
+		e.cachedConfig[e.cacheKey(key)] = val
+	}
+}
 
-func (e *DotEnv) Set(key string, value any) {
-	key = e.addPrefix(key)
-	key = strings.ToUpper(key)
+// SetDefault sets the default value of a key.
+// The default value is used when the key is not set in the environment or the config cache.
+// Defaults are not written by Save. SetDefault panics with ErrFrozen if the config is frozen.
+func SetDefault(key string, value any) { GetDotEnv().SetDefault(key, value) }
+
+func (e *DotEnv) SetDefault(key string, value any) {
+	if e.isFrozen() {
+		panic(ErrFrozen)
+	}
+
+	key = e.normalizeKey(key)
+
+	e.mu.Lock()
+	e.defaults[key] = value
+	e.mu.Unlock()
+}
+
+// Freeze makes the config read-only.
+// Subsequent calls to Load and Write return ErrFrozen, and Set and SetDefault panic.
+func Freeze() { GetDotEnv().Freeze() }
+
+func (e *DotEnv) Freeze() {
+	e.frozen.Store(true)
+}
+
+// isFrozen reports whether e, or the parent of a view, is frozen.
+func (e *DotEnv) isFrozen() bool {
+	return e.frozen.Load() || (e.parent != nil && e.parent.isFrozen())
+}
+
+// UseSetMiddleware registers a middleware to validate or normalize values on Set.
+// Middlewares run in the order they are registered.
+func UseSetMiddleware(m SetMiddleware) { GetDotEnv().UseSetMiddleware(m) }
 
+func (e *DotEnv) UseSetMiddleware(m SetMiddleware) {
 	e.mu.Lock()
-	e.cachedConfig[key] = value
+	e.setMiddleware = append(e.setMiddleware, m)
 	e.mu.Unlock()
 }
 
+// PanicOnSetError tells Dotenv to panic when a SetMiddleware rejects a value passed to Set.
+// By default, the rejected value is ignored. Use SetE to handle the error instead.
+func PanicOnSetError(panicOnSetError bool) { GetDotEnv().PanicOnSetError(panicOnSetError) }
+
+func (e *DotEnv) PanicOnSetError(panicOnSetError bool) {
+	e.panicOnSetError = panicOnSetError
+}
+
 // Deprecated: to be removed in v2.0.0
 //
 // Save writes the current configuration to a file.
@@ -473,15 +3402,138 @@ func Save() error { return GetDotEnv().Save() }
 //
 // Save writes the current configuration to a file.
 func (e *DotEnv) Save() error {
-	cfgData := ""
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	return e.save()
+}
+
+// SaveSync is like Save but also flushes the config file and its directory to stable storage
+// before returning, so the configuration survives a crash or power loss.
+// On Windows, only the file is flushed since directories cannot be synced.
+func SaveSync() error { return GetDotEnv().SaveSync() }
+
+func (e *DotEnv) SaveSync() error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	if err := e.save(); err != nil {
+		return err
+	}
+	file, err := expandPath(e.configFile)
+	if err != nil {
+		return err
+	}
+	if err := syncFile(file); err != nil {
+		return fmt.Errorf("failed to sync config file: %w", err)
+	}
+	return nil
+}
+
+// save writes the current configuration to the config file. The caller must hold writeMu.
+func (e *DotEnv) save() error {
+	e.mu.RLock()
+	data, err := e.encoder.Encode(e.cachedConfig)
+	e.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(e.configFile, e.headerComments()+string(data), e.filePerm)
+}
+
+// SetFileHeader sets the comment lines written at the top of the config file by Save,
+// e.g. "Generated by myapp - do not edit". Lines that don't start with # are prefixed with "# ".
+// Since the header is made of comments, it's ignored when the file is loaded and written again on the next Save.
+func SetFileHeader(lines ...string) { GetDotEnv().SetFileHeader(lines...) }
+
+func (e *DotEnv) SetFileHeader(lines ...string) {
+	e.mu.Lock()
+	e.fileHeader = append([]string(nil), lines...)
+	e.mu.Unlock()
+}
+
+// headerComments returns the header set with SetFileHeader as comment lines.
+func (e *DotEnv) headerComments() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var b strings.Builder
+	for _, line := range e.fileHeader {
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// SaveOverridesOnly is like Save but omits the keys whose value is the same as their default value,
+// so only explicit overrides are written to the file.
+func SaveOverridesOnly() error { return GetDotEnv().SaveOverridesOnly() }
+
+func (e *DotEnv) SaveOverridesOnly() error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	config := make(map[string]any)
+
+	e.mu.RLock()
+	for key, value := range e.cachedConfig {
+		if def, ok := e.defaults[key]; ok && cast.ToString(def) == cast.ToString(value) {
+			continue
+		}
+		config[key] = value
+	}
+	e.mu.RUnlock()
+
+	data, err := e.encoder.Encode(config)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(e.configFile, e.headerComments()+string(data), e.filePerm)
+}
+
+// ExportToFile writes a subset of the current configuration to the given file.
+// Keys can be exact names or glob patterns as supported by path.Match, e.g. "DB_*".
+// All keys are written if none is provided.
+func ExportToFile(file string, keys ...string) error {
+	return GetDotEnv().ExportToFile(file, keys...)
+}
+
+func (e *DotEnv) ExportToFile(file string, keys ...string) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	patterns := make([]string, len(keys))
+	for i, key := range keys {
+		patterns[i] = e.normalizeKey(key)
+	}
+
+	config := make(map[string]any)
 
 	e.mu.RLock()
 	for key, value := range e.cachedConfig {
-		cfgData += fmt.Sprintf("%s=%s\n", key, cast.ToString(value))
+		if len(patterns) == 0 {
+			config[key] = value
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, key); matched {
+				config[key] = value
+				break
+			}
+		}
 	}
 	e.mu.RUnlock()
 
-	return writeConfig(e.configFile, cfgData)
+	data, err := e.encoder.Encode(config)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(file, string(data), e.filePerm)
 }
 
 // Write explicitly sets/update the configuration with the key-value provided
@@ -490,16 +3542,112 @@ func (e *DotEnv) Save() error {
 //
 //	dotenv.Set(key, value)
 //	dotenv.Save()
+//
+// except that concurrent calls to Write and Save are serialized,
+// so the file always holds a complete snapshot of the configuration.
 func Write(key string, value any) error { return GetDotEnv().Write(key, value) }
 
 func (e *DotEnv) Write(key string, value any) error {
-	e.Set(key, value)
-	return e.Save()
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	if err := e.SetE(key, value); err != nil {
+		return err
+	}
+	return e.save()
+}
+
+// WriteWithComment is like Write but keeps the contents of the config file when the key is new:
+// the key is appended to the file after a blank line, preceded by the comment, if any.
+// Each line of the comment is written as a # comment line.
+// If the key is already in the file, the file is rewritten as with Write and the comment is not added.
+func WriteWithComment(key string, value any, comment string) error {
+	return GetDotEnv().WriteWithComment(key, value, comment)
+}
+
+func (e *DotEnv) WriteWithComment(key string, value any, comment string) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	existing, err := e.readFile(e.configFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := e.SetE(key, value); err != nil {
+		return err
+	}
+
+	key = e.normalizeKey(key)
+	if containsKey(existing, key) {
+		return e.save()
+	}
+
+	e.mu.RLock()
+	data, err := e.encoder.Encode(map[string]any{key: e.cachedConfig[key]})
+	e.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.Write(existing)
+	if len(existing) > 0 {
+		if existing[len(existing)-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	if comment != "" {
+		for _, line := range strings.Split(comment, "\n") {
+			b.WriteString(strings.TrimSpace("# "+line) + "\n")
+		}
+	}
+	b.Write(data)
+
+	return writeConfig(e.configFile, b.String(), e.filePerm)
+}
+
+// containsKey reports whether the contents of an env file assign the key.
+func containsKey(data []byte, key string) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, _, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(strings.TrimPrefix(name, "export "))
+		if strings.EqualFold(strings.TrimSuffix(name, "+"), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultFilePerm is the permission of the config files written by Save and the other functions writing config files.
+// It's restricted to the owner since config files often hold secrets.
+const DefaultFilePerm os.FileMode = 0600
+
+// SetFilePerm sets the permission of the config files written by Save, Write and the other
+// functions writing config files. Defaults to DefaultFilePerm.
+func SetFilePerm(perm os.FileMode) { GetDotEnv().SetFilePerm(perm) }
+
+func (e *DotEnv) SetFilePerm(perm os.FileMode) {
+	e.filePerm = perm
 }
 
-func writeConfig(cfgFile, data string) error {
+// writeConfig writes data to the config file with WriteFile, replacing it atomically where possible.
+// The path is expanded like the config files read by Load.
+func writeConfig(cfgFile, data string, perm os.FileMode) error {
+	cfgFile, err := expandPath(cfgFile)
+	if err != nil {
+		return err
+	}
 	_ = os.MkdirAll(filepath.Join(cfgFile, ".."), 0755)
-	if err := os.WriteFile(cfgFile, []byte(data), 0666); err != nil {
+	if err := WriteFile(cfgFile, []byte(data), perm); err != nil {
 		return fmt.Errorf("failed to write to config file: %q", err)
 	}
 
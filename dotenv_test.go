@@ -1,12 +1,28 @@
 package dotenv_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/spf13/cast"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -141,7 +157,8 @@ func TestUnMarshal(t *testing.T) {
 		DoesNotExit:  "default",
 		SomeDuration: time.Second,
 		DB: DB{
-			Host:     "localhost",
+			// APP_DB_HOST is set to an empty value, which suppresses the default
+			Host:     "",
 			Port:     3306,
 			User:     "root",
 			Password: "my-secret-pw",
@@ -222,3 +239,2302 @@ func TestGet_NoConfigFile(t *testing.T) {
 	val = dotenv.GetString("SOME_KEY")
 	assert.Equal(t, "some value", val)
 }
+
+func TestGetStringSliceWithSep(t *testing.T) {
+	env := dotenv.New()
+	env.Set("PATH_LIST", "/usr/bin: /usr/local/bin :/opt/bin:")
+	env.Set("HOSTS", "a.example.com; b.example.com;;")
+	env.Set("PORTS", "80;443; 8080;")
+
+	assert.Equal(t, []string{"/usr/bin", "/usr/local/bin", "/opt/bin"}, env.GetStringSliceWithSep("PATH_LIST", ":"))
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, env.GetStringSliceWithSep("HOSTS", ";"))
+	assert.Equal(t, []int{80, 443, 8080}, env.GetIntSliceWithSep("PORTS", ";"))
+	assert.Equal(t, []string{}, env.GetStringSliceWithSep("DOES_NOT_EXIST", ":"))
+}
+
+func TestGetStringSlice_brackets(t *testing.T) {
+	env := dotenv.New()
+	env.Set("BRACKETED", "[prod,staging]")
+	env.Set("UNBRACKETED", "prod,staging")
+	env.Set("MISMATCHED_END", "prod]extra,staging")
+	env.Set("MISMATCHED_START", "[prod,staging")
+
+	assert.Equal(t, []string{"prod", "staging"}, env.GetStringSlice("BRACKETED"))
+	assert.Equal(t, []string{"prod", "staging"}, env.GetStringSlice("UNBRACKETED"))
+	assert.Equal(t, []string{"prod]extra", "staging"}, env.GetStringSlice("MISMATCHED_END"))
+	assert.Equal(t, []string{"[prod", "staging"}, env.GetStringSlice("MISMATCHED_START"))
+}
+
+func TestUseSetMiddleware(t *testing.T) {
+	env := dotenv.New()
+	env.UseSetMiddleware(func(key string, value any) (any, error) {
+		if key == "PORT" && cast.ToInt(value) < 0 {
+			return nil, errors.New("port cannot be negative")
+		}
+		return value, nil
+	})
+	env.UseSetMiddleware(func(key string, value any) (any, error) {
+		if key == "URL" {
+			return strings.TrimSuffix(cast.ToString(value), "/"), nil
+		}
+		return value, nil
+	})
+
+	require.NoError(t, env.SetE("PORT", 8080))
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+
+	err := env.SetE("PORT", -1)
+	assert.EqualError(t, err, "set PORT: port cannot be negative")
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+
+	// rejected value is ignored by Set
+	env.Set("PORT", -1)
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+
+	env.Set("URL", "https://example.com/")
+	assert.Equal(t, "https://example.com", env.GetString("URL"))
+
+	// the middlewares also run on Set of a view
+	view := env.WithOverrides(nil)
+	err = view.SetE("PORT", -1)
+	assert.EqualError(t, err, "set PORT: port cannot be negative")
+	assert.Equal(t, 8080, view.GetInt("PORT"))
+
+	env.PanicOnSetError(true)
+	assert.Panics(t, func() { env.Set("PORT", -1) })
+}
+
+func TestExportToFile(t *testing.T) {
+	env := dotenv.New()
+	env.Set("DB_HOST", "localhost")
+	env.Set("DB_PASSWORD", `p@ss "word"`)
+	env.Set("APP_NAME", "app")
+
+	file := filepath.Join(t.TempDir(), "db.env")
+	err := env.ExportToFile(file, "DB_*")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "DB_HOST=localhost\nDB_PASSWORD=\"p@ss \\\"word\\\"\"\n", string(data))
+
+	exported := dotenv.New()
+	require.NoError(t, exported.Load(file))
+	assert.Equal(t, "localhost", exported.GetString("DB_HOST"))
+	assert.Equal(t, `p@ss "word"`, exported.GetString("DB_PASSWORD"))
+	assert.False(t, exported.IsSet("APP_NAME"))
+
+	file = filepath.Join(t.TempDir(), "named.env")
+	err = env.ExportToFile(file, "app_name", "db_host")
+	require.NoError(t, err)
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "APP_NAME=app\nDB_HOST=localhost\n", string(data))
+}
+
+func TestGetByPattern(t *testing.T) {
+	env := dotenv.New()
+	env.Set("FEATURE_SEARCH", true)
+	env.Set("FEATURE_CHAT", false)
+	env.Set("FEATURED_POST", "hello")
+	env.Set("APP_NAME", "app")
+
+	t.Setenv("FEATURE_EXPORT", "true")
+	t.Setenv("FEATURE_CHAT", "true")
+
+	assert.Equal(t, map[string]any{
+		"FEATURE_SEARCH": true,
+		"FEATURE_CHAT":   "true",
+		"FEATURE_EXPORT": "true",
+	}, env.GetByPattern("feature_*"))
+}
+
+func TestDotEnv_String(t *testing.T) {
+	env := dotenv.New()
+	env.Set("DB_USER", "root")
+	env.Set("DB_PASSWORD", "my-secret-pw")
+	env.Set("API_KEY", "abc123")
+	env.Set("APP_NAME", "my app")
+
+	t.Setenv("DB_USER", "admin")
+
+	expected := "API_KEY=******\nAPP_NAME=\"my app\"\nDB_PASSWORD=******\nDB_USER=admin\n"
+	assert.Equal(t, expected, env.String())
+	assert.Equal(t, expected, fmt.Sprintf("%v", env))
+}
+
+func TestLoad_appendOperator(t *testing.T) {
+	env := dotenv.New()
+	err := env.Load("fixtures/append_base.env", "fixtures/append_override.env")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/usr/bin,/opt/bin", env.GetString("PATH_LIST"))
+	assert.Equal(t, "base,override", env.GetString("TAGS"))
+	assert.Equal(t, "override", env.GetString("NAME"))
+	assert.Equal(t, "new", env.GetString("NEW_KEY"))
+
+	env = dotenv.New()
+	err = env.LoadWithDecoder(&dotenv.DefaultDecoder{AppendSeparator: ":"}, "fixtures/append_base.env", "fixtures/append_override.env")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/usr/bin:/opt/bin", env.GetString("PATH_LIST"))
+}
+
+func TestRequireKeys(t *testing.T) {
+	env := dotenv.New()
+	env.Set("DB_HOST", "localhost")
+
+	err := env.RequireKeys("DB_HOST", "DB_USER", "DB_PASSWORD")
+	assert.EqualError(t, err, "missing required keys: DB_USER, DB_PASSWORD")
+
+	assert.NoError(t, env.RequireKeys("DB_HOST"))
+}
+
+func TestGetIntInRange(t *testing.T) {
+	env := dotenv.New()
+	env.Set("PORT", "8080")
+	env.Set("WORKERS", "100")
+	env.Set("RETRIES", "three")
+
+	port, err := env.GetIntInRange("PORT", 1, 65535)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	_, err = env.GetIntInRange("WORKERS", 1, 10)
+	assert.EqualError(t, err, "WORKERS: value 100 is out of range [1, 10]")
+
+	_, err = env.GetIntInRange("RETRIES", 0, 5)
+	assert.ErrorContains(t, err, "RETRIES: unable to cast")
+
+	_, err = env.GetIntInRange("DOES_NOT_EXIST", 0, 5)
+	assert.EqualError(t, err, "DOES_NOT_EXIST: key is not set")
+}
+
+func TestGetEnum(t *testing.T) {
+	env := dotenv.New()
+	env.Set("LOG_LEVEL", "debug")
+	env.Set("LOG_FORMAT", "JSON")
+
+	level, err := env.GetEnum("LOG_LEVEL", "debug", "info", "error")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", level)
+
+	_, err = env.GetEnum("LOG_FORMAT", "text", "json")
+	assert.EqualError(t, err, `LOG_FORMAT: invalid value "JSON", must be one of: text, json`)
+
+	format, err := env.GetEnumFold("LOG_FORMAT", "text", "json")
+	require.NoError(t, err)
+	assert.Equal(t, "json", format)
+}
+
+func TestLoadEncrypted(t *testing.T) {
+	key := []byte("secret")
+	xor := func(data []byte) ([]byte, error) {
+		out := make([]byte, len(data))
+		for i := range data {
+			out[i] = data[i] ^ key[i%len(key)]
+		}
+		return out, nil
+	}
+
+	encrypted, err := xor([]byte("DB_USER=root\nDB_PASSWORD=my-secret-pw\n"))
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), ".env.enc")
+	require.NoError(t, os.WriteFile(file, encrypted, 0600))
+
+	env := dotenv.New()
+	err = env.LoadEncrypted(xor, file)
+	require.NoError(t, err)
+	assert.Equal(t, "root", env.GetString("DB_USER"))
+	assert.Equal(t, "my-secret-pw", env.GetString("DB_PASSWORD"))
+
+	err = env.LoadEncrypted(func([]byte) ([]byte, error) {
+		return nil, errors.New("invalid key")
+	}, file)
+	assert.ErrorContains(t, err, "invalid key")
+}
+
+func TestSetFileReader(t *testing.T) {
+	files := map[string]string{
+		"s3://bucket/app.env": "APP_NAME=my app\nAPP_ENV=production\n",
+	}
+
+	env := dotenv.New()
+	env.SetFileReader(func(file string) ([]byte, error) {
+		data, ok := files[file]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(data), nil
+	})
+
+	err := env.Load("s3://bucket/app.env")
+	require.NoError(t, err)
+	assert.Equal(t, "my app", env.GetString("APP_NAME"))
+	assert.Equal(t, "production", env.GetString("APP_ENV"))
+
+	err = env.Load("s3://bucket/missing.env")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadDirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/10-base.env":     {Data: []byte("APP_NAME=myapp\nLOG_LEVEL=info\n")},
+		"config/20-override.env": {Data: []byte("LOG_LEVEL=debug\n")},
+		"config/README.md":       {Data: []byte("LOG_LEVEL=ignored\n")},
+		"other/ignored.env":      {Data: []byte("OTHER=1\n")},
+	}
+
+	env := dotenv.New()
+	require.NoError(t, env.LoadDirFS(fsys, "config"))
+	assert.Equal(t, "myapp", env.GetString("APP_NAME"))
+	assert.Equal(t, "debug", env.GetString("LOG_LEVEL"))
+	assert.False(t, env.IsSet("OTHER"))
+
+	err := env.LoadDirFS(fsys, "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	fsys["config/30-invalid.env"] = &fstest.MapFile{Data: []byte("INVALID LINE\n")}
+	err = env.LoadDirFS(fsys, "config")
+	assert.EqualError(t, err, "failed to load config dir config: config/30-invalid.env: line 1: key cannot contain spaces")
+}
+
+// recordingDecoder records the bytes it decodes and stores them under the DATA key.
+type recordingDecoder struct {
+	received [][]byte
+}
+
+func (d *recordingDecoder) Decode(b []byte, v map[string]any) error {
+	d.received = append(d.received, b)
+	v["DATA"] = string(b)
+	return nil
+}
+
+func TestWithDecoder(t *testing.T) {
+	decoder := &recordingDecoder{}
+	env := dotenv.New(dotenv.WithDecoder(decoder))
+	env.SetFileReader(func(file string) ([]byte, error) {
+		return []byte("contents of " + file), nil
+	})
+
+	require.NoError(t, env.Load("a.env", "b.env"))
+	assert.Equal(t, [][]byte{[]byte("contents of a.env"), []byte("contents of b.env")}, decoder.received)
+	assert.Equal(t, "contents of b.env", env.GetString("DATA"))
+
+	decoder = &recordingDecoder{}
+	env.SetDecoder(decoder)
+	assert.Empty(t, decoder.received, "SetDecoder should not load")
+
+	require.NoError(t, env.Load("c.env"))
+	assert.Equal(t, [][]byte{[]byte("contents of c.env")}, decoder.received)
+}
+
+// spaceDecoder decodes "KEY value" lines.
+type spaceDecoder struct{}
+
+func (spaceDecoder) Decode(b []byte, v map[string]any) error {
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+		v[key] = value
+	}
+	return nil
+}
+
+func TestRegisterDecoderForExt(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(custom, []byte("HOST example.com\nPORT 8080\n"), 0600))
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, err := gz.Write([]byte("LOG_LEVEL debug\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	compressed := filepath.Join(dir, "log.CONF.gz")
+	require.NoError(t, os.WriteFile(compressed, b.Bytes(), 0600))
+
+	env := dotenv.New()
+	env.RegisterDecoderForExt(".conf", spaceDecoder{})
+	require.NoError(t, env.Load(custom, compressed, "fixtures/plain.env"))
+
+	assert.Equal(t, "example.com", env.GetString("HOST"))
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+	assert.Equal(t, "debug", env.GetString("LOG_LEVEL"))
+	// .env files still use the DefaultDecoder
+	assert.Equal(t, "4", env.GetString("OPTION_D"))
+
+	err = dotenv.New().Load(custom)
+	assert.Error(t, err)
+}
+
+func TestDotEnv_Stats(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/normal.env"))
+
+	t.Setenv("STATS_FROM_ENV", "env")
+
+	_ = env.Get("S3_BUCKET")
+	_ = env.Get("SECRET_KEY")
+	_ = env.Get("STATS_FROM_ENV")
+	_ = env.Get("DOES_NOT_EXIST")
+
+	assert.Equal(t, dotenv.Stats{
+		Loads:        1,
+		LookupsEnv:   1,
+		LookupsCache: 2,
+		LookupMisses: 1,
+	}, env.Stats())
+}
+
+func TestLoad_trimValues(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/whitespace.env"))
+
+	assert.Equal(t, "  padded  ", env.GetString("DOUBLE_QUOTED"))
+	assert.Equal(t, "  padded  ", env.GetString("SINGLE_QUOTED"))
+	assert.Equal(t, "padded", env.GetString("UNQUOTED"))
+	assert.Equal(t, "\n  padded\n", env.GetString("MULTILINE"))
+
+	env = dotenv.New()
+	require.NoError(t, env.LoadWithDecoder(&dotenv.DefaultDecoder{TrimValues: true}, "fixtures/whitespace.env"))
+
+	assert.Equal(t, "padded", env.GetString("DOUBLE_QUOTED"))
+	assert.Equal(t, "  padded  ", env.GetString("SINGLE_QUOTED"))
+	assert.Equal(t, "padded", env.GetString("UNQUOTED"))
+	assert.Equal(t, "padded", env.GetString("MULTILINE"))
+}
+
+func TestLoad_quotedWhitespaceOnlyValues(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/whitespace_quoted.env"))
+
+	assert.Equal(t, "   ", env.GetString("DOUBLE_SPACES"))
+	assert.Equal(t, "   ", env.GetString("SINGLE_SPACES"))
+	assert.Equal(t, "\t", env.GetString("DOUBLE_TAB"))
+	assert.Equal(t, "\t", env.GetString("SINGLE_TAB"))
+	assert.Equal(t, "  ", env.GetString("BACKTICK_SPACES"))
+	assert.Equal(t, "  ", env.GetString("DOUBLE_SPACES_COMMENT"))
+	assert.True(t, env.IsSet("UNQUOTED_SPACES"))
+	assert.Equal(t, "", env.GetString("UNQUOTED_SPACES"))
+}
+
+func TestGetFloat64Locale(t *testing.T) {
+	env := dotenv.New()
+	env.Set("RATE", "3,14")
+	env.Set("RATIO", "0.5")
+
+	rate, err := env.GetFloat64Locale("RATE", true)
+	require.NoError(t, err)
+	assert.Equal(t, 3.14, rate)
+
+	_, err = env.GetFloat64Locale("RATE", false)
+	assert.ErrorContains(t, err, `RATE: strconv.ParseFloat: parsing "3,14": invalid syntax`)
+
+	ratio, err := env.GetFloat64Locale("RATIO", false)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+
+	_, err = env.GetFloat64Locale("DOES_NOT_EXIST", true)
+	assert.EqualError(t, err, "DOES_NOT_EXIST: key is not set")
+}
+
+func TestUnMarshal_defaultFieldReferences(t *testing.T) {
+	type config struct {
+		BaseURL string `env:"BASE_URL" default:"${SCHEME}://${HOST}:${PORT}"`
+		Host    string `env:"HOST" default:"localhost"`
+		Port    int    `env:"PORT" default:"8080"`
+		Scheme  string `default:"http"`
+	}
+
+	env := dotenv.New()
+	env.Set("HOST", "example.com")
+
+	cfg := config{}
+	err := env.Unmarshal(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, config{
+		BaseURL: "http://example.com:8080",
+		Host:    "example.com",
+		Port:    8080,
+		Scheme:  "http",
+	}, cfg)
+
+	type cyclic struct {
+		A string `env:"A" default:"${B}"`
+		B string `env:"B" default:"${A}"`
+	}
+
+	err = env.Unmarshal(&cyclic{})
+	assert.EqualError(t, err, "cycle detected in default value of field A")
+}
+
+func TestGetStringMapStringSlice(t *testing.T) {
+	env := dotenv.New()
+	env.Set("ROUTES", "web:80,443;api:8080")
+	env.Set("EMPTY_ROUTES", "")
+	env.Set("CUSTOM_ROUTES", "web=80 443|api=8080")
+
+	assert.Equal(t, map[string][]string{
+		"web": {"80", "443"},
+		"api": {"8080"},
+	}, env.GetStringMapStringSlice("ROUTES"))
+	assert.Equal(t, map[string][]string{}, env.GetStringMapStringSlice("EMPTY_ROUTES"))
+	assert.Equal(t, map[string][]string{
+		"web": {"80", "443"},
+		"api": {"8080"},
+	}, env.GetStringMapStringSliceWithSep("CUSTOM_ROUTES", "|", "=", " "))
+}
+
+func TestRawValue(t *testing.T) {
+	env := dotenv.New()
+	err := env.LoadWithDecoder(&dotenv.DefaultDecoder{KeepRawValues: true}, "fixtures/quoted.env")
+	require.NoError(t, err)
+
+	assert.Equal(t, "\n", env.GetString("OPTION_H"))
+	raw, ok := env.RawValue("OPTION_H")
+	assert.True(t, ok)
+	assert.Equal(t, `"\n"`, raw)
+
+	raw, ok = env.RawValue("OPTION_I")
+	assert.True(t, ok)
+	assert.Equal(t, `"echo 'asd'" # Inline comment`, raw)
+
+	_, ok = env.RawValue("DOES_NOT_EXIST")
+	assert.False(t, ok)
+
+	env = dotenv.New()
+	require.NoError(t, env.Load("fixtures/quoted.env"))
+	_, ok = env.RawValue("OPTION_H")
+	assert.False(t, ok)
+}
+
+func TestUnMarshal_stdlibTypes(t *testing.T) {
+	type config struct {
+		IP      net.IP         `env:"BIND_IP"`
+		URL     *url.URL       `env:"ENDPOINT"`
+		Pattern *regexp.Regexp `env:"PATTERN"`
+	}
+
+	env := dotenv.New()
+	env.Set("BIND_IP", "192.168.1.10")
+	env.Set("ENDPOINT", "https://example.com/api?v=1")
+	env.Set("PATTERN", `^user-\d+$`)
+
+	cfg := config{}
+	err := env.Unmarshal(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, net.ParseIP("192.168.1.10"), cfg.IP)
+	assert.Equal(t, "example.com", cfg.URL.Host)
+	assert.Equal(t, "/api", cfg.URL.Path)
+	assert.True(t, cfg.Pattern.MatchString("user-42"))
+
+	tests := []struct {
+		key, value, err string
+	}{
+		// net.IP is populated with its UnmarshalText method
+		{"BIND_IP", "not-an-ip", "field IP: invalid IP address: not-an-ip"},
+		{"ENDPOINT", "http://[::1", `field URL: parse "http://[::1": missing ']' in host`},
+		{"PATTERN", "user-(", "field Pattern: error parsing regexp: missing closing ): `user-(`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			env := dotenv.New()
+			env.Set(tt.key, tt.value)
+			err := env.Unmarshal(&config{})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestDotEnv_Freeze(t *testing.T) {
+	env := dotenv.New()
+	env.SetConfigFile(filepath.Join(t.TempDir(), ".env"))
+	env.Set("APP_NAME", "app")
+	env.Freeze()
+
+	assert.ErrorIs(t, env.SetE("APP_NAME", "other"), dotenv.ErrFrozen)
+	assert.PanicsWithError(t, dotenv.ErrFrozen.Error(), func() { env.Set("APP_NAME", "other") })
+	assert.ErrorIs(t, env.Write("APP_NAME", "other"), dotenv.ErrFrozen)
+	assert.ErrorIs(t, env.Load("fixtures/normal.env"), dotenv.ErrFrozen)
+	assert.PanicsWithValue(t, dotenv.ErrFrozen, func() { env.SetDefault("APP_NAME_DEFAULT", "mutated") })
+	assert.Equal(t, "app", env.GetString("APP_NAME"))
+	assert.False(t, env.IsSet("S3_BUCKET"))
+	assert.False(t, env.IsSet("APP_NAME_DEFAULT"))
+}
+
+func TestUnmarshalCollect(t *testing.T) {
+	type DB struct {
+		Port int `env:"DB_PORT"`
+	}
+	type config struct {
+		Workers int           `env:"WORKERS"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Debug   bool          `env:"DEBUG"`
+		Name    string        `env:"NAME"`
+		DB      DB
+	}
+
+	env := dotenv.New()
+	env.Set("WORKERS", "many")
+	env.Set("TIMEOUT", "10s")
+	env.Set("DEBUG", "maybe")
+	env.Set("NAME", "app")
+	env.Set("DB_PORT", "port")
+
+	cfg := config{}
+	errs := env.UnmarshalCollect(&cfg)
+	require.Len(t, errs, 3)
+	assert.ErrorContains(t, errs[0], "field Workers:")
+	assert.ErrorContains(t, errs[1], "field Debug:")
+	assert.ErrorContains(t, errs[2], "field Port:")
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, "app", cfg.Name)
+
+	err := env.Unmarshal(&config{})
+	assert.ErrorContains(t, err, "field Workers:")
+}
+
+func TestUnmarshal_emptyValueSuppressesDefault(t *testing.T) {
+	type config struct {
+		Name  string `env:"NAME" default:"anonymous"`
+		Title string `env:"TITLE" default:"none"`
+		Count int    `env:"COUNT" default:"5"`
+	}
+
+	env := dotenv.New()
+	require.NoError(t, env.LoadReader(strings.NewReader("NAME=\nCOUNT=0\n")))
+
+	cfg := config{Name: "unchanged"}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, config{Name: "", Title: "none", Count: 0}, cfg)
+
+	// fields of unset keys without a default are left unchanged
+	var unset struct {
+		Name string `env:"NAME"`
+	}
+	unset.Name = "unchanged"
+	require.NoError(t, dotenv.New().Unmarshal(&unset))
+	assert.Equal(t, "unchanged", unset.Name)
+}
+
+func TestUnmarshal_debug(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST" default:"localhost"`
+		Port    int    `env:"PORT"`
+		Debug   bool   `env:"DEBUG"`
+		Workers int    `env:"WORKERS"`
+		Name    string `env:"NAME"`
+		Version string `default:"v1"`
+	}
+
+	var trace []string
+	env := dotenv.New(dotenv.WithUnmarshalDebug(func(field, key, source, value string) {
+		trace = append(trace, fmt.Sprintf("%s %s %s=%q", field, source, key, value))
+	}))
+	t.Setenv("TRACE_PORT", "8080")
+	env.SetPrefix("trace")
+	env.Set("DEBUG", "true")
+	env.SetDefault("WORKERS", 4)
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, []string{
+		`Host default TRACE_HOST="localhost"`,
+		`Port env TRACE_PORT="8080"`,
+		`Debug cache TRACE_DEBUG="true"`,
+		`Workers default TRACE_WORKERS="4"`,
+		`Name skipped TRACE_NAME=""`,
+		`Version default ="v1"`,
+	}, trace)
+}
+
+func TestUnmarshal_structMap(t *testing.T) {
+	type service struct {
+		Host      string `env:"HOST" default:"localhost"`
+		Port      int    `env:"PORT"`
+		AdminPort int    `env:"ADMIN_PORT"`
+	}
+	type config struct {
+		Services map[string]service `env:"SERVICES"`
+		Backends map[string]service
+	}
+
+	env := dotenv.New()
+	env.Set("SERVICES_WEB_PORT", "8080")
+	env.Set("SERVICES_WEB_ADMIN_PORT", "9090")
+	env.Set("SERVICES_AUTH_API_HOST", "auth.internal")
+	env.Set("SERVICES_AUTH_API_PORT", "8443")
+	env.Set("SERVICES_UNKNOWN", "ignored")
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, map[string]service{
+		"web":      {Host: "localhost", Port: 8080, AdminPort: 9090},
+		"auth_api": {Host: "auth.internal", Port: 8443},
+	}, cfg.Services)
+	assert.Nil(t, cfg.Backends)
+
+	env.Set("BACKENDS_DB_PORT", "port")
+	err := env.Unmarshal(&cfg)
+	assert.ErrorContains(t, err, "field Port:")
+}
+
+func TestUnmarshalWithPrefix(t *testing.T) {
+	type DB struct {
+		Host string `env:"DB_HOST"`
+		Port int    `env:"DB_PORT" default:"5432"`
+		URL  string `env:"DB_URL" default:"${DB_HOST}:${DB_PORT}"`
+	}
+
+	env := dotenv.New()
+	env.Set("PRIMARY_DB_HOST", "primary")
+	env.Set("REPLICA_DB_HOST", "replica")
+	env.Set("REPLICA_DB_PORT", "5433")
+	env.SetPrefix("app")
+
+	var wg sync.WaitGroup
+	var primary, replica DB
+	var primaryErr, replicaErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		primaryErr = env.UnmarshalWithPrefix(&primary, "primary")
+	}()
+	go func() {
+		defer wg.Done()
+		replicaErr = env.UnmarshalWithPrefix(&replica, "replica")
+	}()
+	wg.Wait()
+
+	require.NoError(t, primaryErr)
+	require.NoError(t, replicaErr)
+	assert.Equal(t, DB{Host: "primary", Port: 5432, URL: "primary:5432"}, primary)
+	assert.Equal(t, DB{Host: "replica", Port: 5433, URL: "replica:5433"}, replica)
+	assert.Equal(t, "APP", env.GetPrefix())
+}
+
+func TestLoad_expandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APP_CONFIG_DIR", filepath.Join(home, "app"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "app", ".env"), []byte("SOURCE=tilde\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "app", ".env"), []byte("SOURCE=env\n"), 0600))
+
+	env := dotenv.New()
+	env.SetConfigFile("~/.config/app/.env")
+	require.NoError(t, env.Load())
+	assert.Equal(t, "tilde", env.GetString("SOURCE"))
+
+	env.SetConfigFile("$HOME/app/.env")
+	require.NoError(t, env.Load())
+	assert.Equal(t, "env", env.GetString("SOURCE"))
+
+	require.NoError(t, env.Load("~/.config/app/.env"))
+	assert.Equal(t, "tilde", env.GetString("SOURCE"))
+
+	env.SetConfigFile("${APP_CONFIG_DIR}/.env")
+	require.NoError(t, env.Load())
+	assert.Equal(t, "env", env.GetString("SOURCE"))
+}
+
+func TestSave_expandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join(home, ".config", "app", ".env")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte("SOURCE=tilde\n"), 0600))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	env := dotenv.New()
+	env.SetConfigFile("~/.config/app/.env")
+	require.NoError(t, env.Load())
+	assert.Equal(t, file, env.ConfigFileUsed())
+
+	env.Set("PORT", "8080")
+	require.NoError(t, env.Save())
+	require.NoError(t, env.SaveSync())
+	require.NoError(t, env.WriteWithComment("HOST", "localhost", "the host"))
+
+	_, err = os.Stat("~")
+	assert.ErrorIs(t, err, os.ErrNotExist, "the path should not be written literally")
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	assert.Equal(t, "tilde", loaded.GetString("SOURCE"))
+	assert.Equal(t, "8080", loaded.GetString("PORT"))
+	assert.Equal(t, "localhost", loaded.GetString("HOST"))
+}
+
+func TestLoad_gzip(t *testing.T) {
+	data, err := os.ReadFile("fixtures/normal.env")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	file := filepath.Join(t.TempDir(), ".env.gz")
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0600))
+
+	env := dotenv.New()
+	err = env.Load(file)
+	require.NoError(t, err)
+	assert.Equal(t, "yours3bucket", env.GetString("S3_BUCKET"))
+	assert.Equal(t, "yoursecretKey", env.GetString("SECRET_KEY"))
+	assert.Equal(t, 2, env.GetInt("PRIORITY_LEVEL"))
+}
+
+func TestDotEnv_AllKeys(t *testing.T) {
+	env := dotenv.New()
+	env.SetPrefix("myapp")
+	env.Set("NAME", "app")
+	env.Set("PORT", 8080)
+
+	t.Setenv("MYAPP_PORT", "9090")
+	t.Setenv("MYAPP_DEBUG", "true")
+	t.Setenv("OTHERAPP_NAME", "other")
+
+	assert.Equal(t, []string{"MYAPP_NAME", "MYAPP_PORT"}, env.Keys())
+	assert.Equal(t, []string{"DEBUG", "NAME", "PORT"}, env.AllKeys())
+}
+
+func TestDotEnv_Reload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("APP_NAME=app\nAPP_ENV=dev\n"), 0600))
+
+	env := dotenv.New()
+	assert.EqualError(t, env.Reload(), "dotenv: no config file has been loaded")
+
+	require.NoError(t, env.Load(file))
+	env.Set("RUNTIME", "set")
+	assert.Equal(t, "dev", env.GetString("APP_ENV"))
+
+	require.NoError(t, os.WriteFile(file, []byte("APP_NAME=new app\n"), 0600))
+	require.NoError(t, env.Reload())
+
+	assert.Equal(t, "new app", env.GetString("APP_NAME"))
+	assert.False(t, env.IsSet("APP_ENV"))
+	assert.Equal(t, "set", env.GetString("RUNTIME"))
+}
+
+func TestGetBoolStrict(t *testing.T) {
+	env := dotenv.New()
+
+	for value, expected := range map[string]bool{
+		"true": true, "TRUE": true, "Yes": true,
+		"false": false, "False": false, "no": false,
+	} {
+		env.Set("FEATURE", value)
+		b, err := env.GetBoolStrict("FEATURE")
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, b, value)
+	}
+
+	for _, value := range []string{"1", "t", "tru", "on", ""} {
+		env.Set("FEATURE", value)
+		_, err := env.GetBoolStrict("FEATURE")
+		assert.Error(t, err, value)
+	}
+
+	env.Set("FEATURE", "tru")
+	_, err := env.GetBoolStrict("FEATURE")
+	assert.EqualError(t, err, `FEATURE: invalid boolean value "tru"`)
+}
+
+func TestDotEnv_NormalizeKeys(t *testing.T) {
+	env := dotenv.New()
+	env.NormalizeKeys(true)
+	require.NoError(t, env.Load("fixtures/dashed.env"))
+
+	assert.True(t, env.GetBool("my-feature-flag"))
+	assert.True(t, env.GetBool("MY_FEATURE_FLAG"))
+	assert.Equal(t, "debug", env.GetString("app.log.level"))
+	assert.Equal(t, "debug", env.GetString("APP_LOG_LEVEL"))
+
+	t.Setenv("MY_FEATURE_FLAG", "false")
+	assert.False(t, env.GetBool("my-feature-flag"))
+
+	env.Set("cache.ttl", "1m")
+	assert.Equal(t, time.Minute, env.GetDuration("CACHE_TTL"))
+
+	env = dotenv.New()
+	require.NoError(t, env.Load("fixtures/dashed.env"))
+	assert.Equal(t, "true", env.GetString("my-feature-flag"))
+	assert.False(t, env.IsSet("APP_LOG_LEVEL"))
+}
+
+func TestGetStringTrimmed(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/whitespace.env"))
+	env.Set("PADDED", "\t value \n")
+
+	assert.Equal(t, "  padded  ", env.GetString("DOUBLE_QUOTED"))
+	assert.Equal(t, "padded", env.GetStringTrimmed("DOUBLE_QUOTED"))
+	assert.Equal(t, "\t value \n", env.GetString("PADDED"))
+	assert.Equal(t, "value", env.GetStringTrimmed("PADDED"))
+}
+
+func TestGetIntBase(t *testing.T) {
+	env := dotenv.New()
+	env.Set("MASK", "0x1F")
+	env.Set("MODE", "0o755")
+	env.Set("FLAGS", "0b1010")
+	env.Set("LEGACY_MODE", "0755")
+	env.Set("COUNT", "42")
+
+	tests := []struct {
+		key      string
+		base     int
+		expected int64
+	}{
+		{"MASK", 0, 31},
+		{"MODE", 0, 0o755},
+		{"FLAGS", 0, 10},
+		{"LEGACY_MODE", 0, 0o755},
+		{"LEGACY_MODE", 8, 0o755},
+		{"LEGACY_MODE", 10, 755},
+		{"COUNT", 10, 42},
+		{"COUNT", 16, 0x42},
+	}
+	for _, tt := range tests {
+		i, err := env.GetIntBase(tt.key, tt.base)
+		require.NoError(t, err, tt.key)
+		assert.Equal(t, tt.expected, i, tt.key)
+	}
+
+	_, err := env.GetIntBase("MASK", 10)
+	assert.EqualError(t, err, `MASK: strconv.ParseInt: parsing "0x1F": invalid syntax`)
+}
+
+func TestGetIndexedSlice(t *testing.T) {
+	env := dotenv.New()
+	env.Set("SERVER_0", "a.example.com")
+	env.Set("SERVER_1", "b.example.com")
+	env.Set("SERVER_2", "c.example.com")
+	env.Set("SERVER_4", "e.example.com")
+
+	expected := []string{"a.example.com", "b.example.com", "c.example.com"}
+	assert.Equal(t, expected, env.GetIndexedSlice("SERVER"))
+	assert.Equal(t, expected, env.GetIndexedSlice("server_"))
+	assert.Equal(t, []string{}, env.GetIndexedSlice("CLIENT"))
+}
+
+func TestUnmarshalMap(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/normal.env"))
+	env.Set("EXTRA", 42)
+	t.Setenv("PRIORITY_LEVEL", "5")
+
+	var m map[string]string
+	require.NoError(t, env.Unmarshal(&m))
+	assert.Equal(t, map[string]string{
+		"S3_BUCKET":      "yours3bucket",
+		"SECRET_KEY":     "yoursecretKey",
+		"PRIORITY_LEVEL": "5",
+		"EXTRA":          "42",
+	}, m)
+
+	existing := map[string]string{"OTHER": "value"}
+	require.NoError(t, env.UnmarshalMap(&existing))
+	assert.Len(t, existing, 5)
+	assert.Equal(t, "value", existing["OTHER"])
+}
+
+func TestLoadBacktickQuotedEnv(t *testing.T) {
+	envFileName := "fixtures/backtick.env"
+	expectedValues := map[string]string{
+		"QUOTES":    `He said "it's fine"`,
+		"LITERAL":   `C:\new\path $HOME ${USER}`,
+		"MULTILINE": "first \"line\"\nsecond 'line'",
+	}
+
+	testReadEnvAndCompare(t, envFileName, expectedValues)
+}
+
+func TestGetLines(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.Load("fixtures/multiline.env"))
+
+	expected := []string{"a.example.com", "b.example.com", "c.example.com"}
+	assert.Equal(t, expected, env.GetLines("HOSTS"))
+	assert.Equal(t, expected, env.GetLines("SINGLE_LINE_HOSTS"))
+	assert.Equal(t, []string{}, env.GetLines("DOES_NOT_EXIST"))
+}
+
+func TestDotEnv_SaveOverridesOnly(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.SetDefault("PORT", 8080)
+	env.SetDefault("HOST", "localhost")
+	env.SetDefault("LOG_LEVEL", "info")
+
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+	assert.Equal(t, "info", env.GetString("LOG_LEVEL"))
+
+	env.Set("PORT", "8080")
+	env.Set("HOST", "example.com")
+
+	require.NoError(t, env.SaveOverridesOnly())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "HOST=example.com\n", string(data))
+
+	require.NoError(t, env.Save())
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "HOST=example.com\nPORT=8080\n", string(data))
+}
+
+func TestSaveSync(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config", ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.Set("PORT", "8080")
+	env.Set("HOST", "example.com")
+	require.NoError(t, env.SaveSync())
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	assert.Equal(t, "example.com", loaded.GetString("HOST"))
+	assert.Equal(t, 8080, loaded.GetInt("PORT"))
+}
+
+func TestGetStringf(t *testing.T) {
+	env := dotenv.New()
+	env.Set("TENANT_1_NAME", "acme")
+	env.Set("TENANT_1_SHARDS", "4")
+	env.Set("TENANT_2_NAME", "globex")
+	env.Set("TENANT_2_ACTIVE", "true")
+
+	assert.Equal(t, "acme", env.GetStringf("TENANT_%d_NAME", 1))
+	assert.Equal(t, "globex", env.GetStringf("TENANT_%d_%s", 2, "NAME"))
+	assert.Equal(t, "globex", env.Getf("tenant_%d_name", 2))
+	assert.Equal(t, 4, env.GetIntf("TENANT_%d_SHARDS", 1))
+	assert.True(t, env.GetBoolf("TENANT_%d_ACTIVE", 2))
+	assert.Equal(t, "", env.GetStringf("TENANT_%d_NAME", 3))
+}
+
+type dsn struct {
+	value string
+}
+
+// check that it implements dotenv.EnvUnmarshaler
+var _ dotenv.EnvUnmarshaler = (*dsn)(nil)
+
+func (d *dsn) UnmarshalEnv(e *dotenv.DotEnv, key string) error {
+	if err := e.RequireKeys(key+"_HOST", key+"_PORT"); err != nil {
+		return err
+	}
+	d.value = fmt.Sprintf("%s@%s:%d/%s",
+		e.GetString(key+"_USER"), e.GetString(key+"_HOST"), e.GetInt(key+"_PORT"), e.GetString(key+"_NAME"))
+	return nil
+}
+
+func TestUnMarshal_fieldWithEnvUnmarshaler(t *testing.T) {
+	type config struct {
+		DB      dsn `env:"DB"`
+		Replica dsn `env:"REPLICA"`
+	}
+
+	env := dotenv.New()
+	env.Set("DB_USER", "root")
+	env.Set("DB_HOST", "localhost")
+	env.Set("DB_PORT", "5432")
+	env.Set("DB_NAME", "app")
+
+	cfg := config{}
+	err := env.Unmarshal(&cfg)
+	assert.EqualError(t, err, "field Replica: missing required keys: REPLICA_HOST, REPLICA_PORT")
+	assert.Equal(t, "root@localhost:5432/app", cfg.DB.value)
+}
+
+func TestConfigFileUsed(t *testing.T) {
+	env := dotenv.New()
+	assert.Empty(t, env.ConfigFileUsed())
+
+	require.NoError(t, env.Load("fixtures/plain.env"))
+	assert.Equal(t, "fixtures/plain.env", env.ConfigFileUsed())
+
+	require.NoError(t, env.Load("fixtures/append_base.env", "fixtures/append_override.env"))
+	assert.Equal(t, "fixtures/append_override.env", env.ConfigFileUsed())
+
+	// a failed load doesn't change the file used
+	require.Error(t, env.Load("fixtures/unknown.env"))
+	assert.Equal(t, "fixtures/append_override.env", env.ConfigFileUsed())
+}
+
+func TestDotEnv_SetConfigFileE(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.SetConfigFileE("fixtures/plain.env"))
+	require.NoError(t, env.Load())
+	assert.Equal(t, "fixtures/plain.env", env.ConfigFileUsed())
+
+	assert.EqualError(t, env.SetConfigFileE(""), "dotenv: config file path cannot be empty")
+	assert.EqualError(t, env.SetConfigFileE("  "), "dotenv: config file path cannot be empty")
+	// the config file is unchanged
+	require.NoError(t, env.Load())
+	assert.Equal(t, "fixtures/plain.env", env.ConfigFileUsed())
+}
+
+func TestSetKeyPolicy(t *testing.T) {
+	t.Run("allow", func(t *testing.T) {
+		env := dotenv.New()
+		require.NoError(t, env.Load("fixtures/invalid_keys.env"))
+
+		assert.Equal(t, "bar", env.GetString("1FOO"))
+		assert.Equal(t, "baz", env.GetString("FOO-BAR"))
+		assert.Equal(t, "ok", env.GetString("VALID_KEY"))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, content := range []string{"1FOO=bar", "FOO-BAR=baz"} {
+			env := dotenv.New()
+			env.SetKeyPolicy(dotenv.KeyPolicyReject)
+			env.SetFileReader(func(string) ([]byte, error) { return []byte(content), nil })
+
+			err := env.Load()
+			assert.ErrorContains(t, err, "invalid key")
+		}
+	})
+
+	t.Run("sanitize", func(t *testing.T) {
+		env := dotenv.New()
+		env.SetKeyPolicy(dotenv.KeyPolicySanitize)
+		require.NoError(t, env.Load("fixtures/invalid_keys.env"))
+
+		assert.Equal(t, "bar", env.GetString("_1FOO"))
+		assert.Equal(t, "baz", env.GetString("FOO_BAR"))
+		assert.Equal(t, "ok", env.GetString("VALID_KEY"))
+		assert.False(t, env.IsSet("1FOO"))
+		assert.False(t, env.IsSet("FOO-BAR"))
+	})
+}
+
+func TestGetDurationSlice(t *testing.T) {
+	env := dotenv.New()
+	env.Set("BACKOFFS", "100ms, 1s,5s,30s")
+	env.Set("INVALID_BACKOFFS", "100ms,soon,5s")
+
+	want := []time.Duration{100 * time.Millisecond, time.Second, 5 * time.Second, 30 * time.Second}
+	assert.Equal(t, want, env.GetDurationSlice("BACKOFFS"))
+
+	got, err := env.GetDurationSliceE("BACKOFFS")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 0, 5 * time.Second}, env.GetDurationSlice("INVALID_BACKOFFS"))
+
+	_, err = env.GetDurationSliceE("INVALID_BACKOFFS")
+	assert.EqualError(t, err, `INVALID_BACKOFFS: invalid duration "soon" at index 1`)
+
+	assert.Empty(t, env.GetDurationSlice("UNSET_BACKOFFS"))
+}
+
+func TestWithEnvKeyReplacer(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+
+	env := dotenv.New(dotenv.WithEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_")))
+	env.Set("db.port", "5432")
+
+	assert.Equal(t, "db.internal", env.GetString("db.host"))
+	assert.Equal(t, "5432", env.GetString("db.port"))
+
+	// without a replacer the dotted key doesn't match the environment variable
+	assert.False(t, dotenv.New().IsSet("db.host"))
+}
+
+func TestLoadAndApply(t *testing.T) {
+	keys := []string{"OPTION_A", "OPTION_B", "OPTION_C", "OPTION_D", "OPTION_E", "OPTION_F", "OPTION_G", "OPTION_H"}
+	unsetEnv := func() {
+		for _, key := range keys {
+			t.Setenv(key, "") // restores the environment on cleanup
+			require.NoError(t, os.Unsetenv(key))
+		}
+	}
+
+	t.Run("without overwrite", func(t *testing.T) {
+		unsetEnv()
+		t.Setenv("OPTION_B", "fromEnv")
+
+		env := dotenv.New()
+		require.NoError(t, env.LoadAndApply(false, "fixtures/plain.env"))
+
+		assert.Equal(t, "1", env.GetString("OPTION_A"))
+		assert.Equal(t, "1", os.Getenv("OPTION_A"))
+		assert.Equal(t, "my string", os.Getenv("OPTION_H"))
+		assert.Equal(t, "fromEnv", os.Getenv("OPTION_B"))
+		assert.Equal(t, "fromEnv", env.GetString("OPTION_B"))
+	})
+
+	t.Run("with overwrite", func(t *testing.T) {
+		unsetEnv()
+		t.Setenv("OPTION_B", "fromEnv")
+
+		env := dotenv.New()
+		require.NoError(t, env.LoadAndApply(true, "fixtures/plain.env"))
+
+		assert.Equal(t, "1", os.Getenv("OPTION_A"))
+		assert.Equal(t, "2", os.Getenv("OPTION_B"))
+		assert.Equal(t, "2", env.GetString("OPTION_B"))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		unsetEnv()
+
+		env := dotenv.New()
+		assert.ErrorIs(t, env.LoadAndApply(true, "fixtures/unknown.env"), os.ErrNotExist)
+		_, ok := os.LookupEnv("OPTION_A")
+		assert.False(t, ok)
+	})
+}
+
+func TestLoadReader(t *testing.T) {
+	env := dotenv.New()
+	err := env.LoadReader(strings.NewReader("\uFEFFFOO=bar\nBAZ=qux\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "bar", env.GetString("FOO"))
+	assert.Equal(t, "qux", env.GetString("BAZ"))
+	assert.Equal(t, []string{"BAZ", "FOO"}, env.Keys())
+
+	// a BOM is only stripped at the start of the content
+	require.NoError(t, env.LoadReader(strings.NewReader("A=1\n\uFEFFB=2")))
+	assert.False(t, env.IsSet("B"))
+	assert.Equal(t, "2", env.GetString("\uFEFFB"))
+}
+
+func TestWithDefaultFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".myapp.env"), []byte("NAME=myapp"), 0o600))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	env := dotenv.New(dotenv.WithDefaultFile(".myapp.env"))
+	require.NoError(t, env.Load())
+
+	assert.Equal(t, "myapp", env.GetString("NAME"))
+	assert.Equal(t, ".myapp.env", env.ConfigFileUsed())
+}
+
+func TestDotEnv_WriteWithComment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("# database\nDB_HOST=localhost\n"), 0o600))
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	require.NoError(t, env.Load())
+
+	require.NoError(t, env.WriteWithComment("CACHE_URL", "redis://localhost:6379", "cache settings"))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "# database\nDB_HOST=localhost\n\n# cache settings\nCACHE_URL=redis://localhost:6379\n", string(data))
+	assert.Equal(t, "redis://localhost:6379", env.GetString("CACHE_URL"))
+
+	require.NoError(t, env.WriteWithComment("DEBUG", true, ""))
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), "CACHE_URL=redis://localhost:6379\n\nDEBUG=true\n"))
+
+	// updating an existing key rewrites the file, as with Write
+	require.NoError(t, env.WriteWithComment("DB_HOST", "db.internal", "ignored"))
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "CACHE_URL=redis://localhost:6379\nDB_HOST=db.internal\nDEBUG=true\n", string(data))
+}
+
+func TestDotEnv_WriteWithComment_fileReader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	var read []string
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.SetFileReader(func(name string) ([]byte, error) {
+		read = append(read, name)
+		return os.ReadFile(name)
+	})
+	require.NoError(t, env.WriteWithComment("HOST", "localhost", ""))
+	assert.Equal(t, []string{file}, read)
+}
+
+func TestGetRune(t *testing.T) {
+	env := dotenv.New()
+	env.Set("CSV_DELIM", ";")
+	env.Set("BULLET", "•")
+	env.Set("EMPTY", "")
+	env.Set("WORD", "ab")
+
+	assert.Equal(t, ';', env.GetRune("CSV_DELIM"))
+	assert.Equal(t, '•', env.GetRune("BULLET"))
+	assert.Equal(t, rune(0), env.GetRune("EMPTY"))
+	assert.Equal(t, 'a', env.GetRune("WORD"))
+
+	r, err := env.GetRuneE("CSV_DELIM")
+	require.NoError(t, err)
+	assert.Equal(t, ';', r)
+
+	r, err = env.GetRuneE("BULLET")
+	require.NoError(t, err)
+	assert.Equal(t, '•', r)
+
+	_, err = env.GetRuneE("EMPTY")
+	assert.EqualError(t, err, "EMPTY: value is empty")
+
+	_, err = env.GetRuneE("WORD")
+	assert.EqualError(t, err, `WORD: value "ab" is not a single character`)
+
+	_, err = env.GetRuneE("UNSET")
+	assert.EqualError(t, err, "UNSET: key is not set")
+}
+
+func TestDotEnv_WriteConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, env.Write(fmt.Sprintf("KEY_%d", i), fmt.Sprintf("value %d", i)))
+			_ = env.GetString("KEY_0")
+		}(i)
+	}
+	wg.Wait()
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	for i := 0; i < writers; i++ {
+		assert.Equal(t, fmt.Sprintf("value %d", i), loaded.GetString(fmt.Sprintf("KEY_%d", i)))
+	}
+}
+
+func TestGetStringMapString(t *testing.T) {
+	env := dotenv.New()
+	env.Set("TAGS", "env:prod, region : eu")
+	env.Set("INVALID_TAGS", "env:prod,region")
+
+	assert.Equal(t, map[string]string{"env": "prod", "region": "eu"}, env.GetStringMapString("TAGS"))
+	assert.Equal(t, map[string]string{"env": "prod"}, env.GetStringMapString("INVALID_TAGS"))
+	assert.Empty(t, env.GetStringMapString("UNSET_TAGS"))
+
+	_, err := env.GetStringMapStringE("INVALID_TAGS")
+	assert.EqualError(t, err, `INVALID_TAGS: invalid map entry "region": missing separator ':'`)
+}
+
+func TestUnMarshal_mapFields(t *testing.T) {
+	type config struct {
+		Labels map[string]string `env:"LABELS"`
+		Limits map[string]int    `env:"LIMITS"`
+	}
+
+	env := dotenv.New()
+	env.Set("LABELS", "team:core,tier:backend")
+	env.Set("LIMITS", "cpu:2,memory:512")
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, map[string]string{"team": "core", "tier": "backend"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"cpu": 2, "memory": 512}, cfg.Limits)
+
+	tests := []struct {
+		key, value, err string
+	}{
+		{"LABELS", "team:core,tier", `field Labels: invalid map entry "tier": missing separator ':'`},
+		{"LIMITS", "cpu2", `field Limits: invalid map entry "cpu2": missing separator ':'`},
+		{"LIMITS", "cpu:two", `field Limits: invalid map value for "cpu"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			env := dotenv.New()
+			env.Set(tt.key, tt.value)
+			err := env.Unmarshal(&config{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestUnMarshal_arrayFields(t *testing.T) {
+	type config struct {
+		Ports [3]int    `env:"PORTS"`
+		Hosts [2]string `env:"HOSTS"`
+	}
+
+	env := dotenv.New()
+	env.Set("PORTS", "80, 443, 8080")
+	env.Set("HOSTS", "[a.example.com,b.example.com]")
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, [3]int{80, 443, 8080}, cfg.Ports)
+	assert.Equal(t, [2]string{"a.example.com", "b.example.com"}, cfg.Hosts)
+
+	tests := []struct {
+		key, value, err string
+	}{
+		{"PORTS", "80,443", "field Ports: expected 3 elements, got 2"},
+		{"PORTS", "80,443,8080,9090", "field Ports: expected 3 elements, got 4"},
+		{"PORTS", "80,https,8080", "field Ports: unable to cast"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			env := dotenv.New()
+			env.Set(tt.key, tt.value)
+			err := env.Unmarshal(&config{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	type unsupported struct {
+		Chans [2]chan int `env:"CHANS"`
+	}
+	env.Set("CHANS", "a,b")
+	assert.EqualError(t, env.Unmarshal(&unsupported{}), "field Chans: unsupported type [2]chan int")
+}
+
+func TestUnMarshal_withValidator(t *testing.T) {
+	type config struct {
+		Port    int    `env:"PORT" validate:"min=1,max=65535"`
+		Workers int    `env:"WORKERS" default:"4"`
+		Name    string `env:"NAME"`
+	}
+
+	errPortOutOfRange := errors.New("PORT must be between 1 and 65535")
+	validator := func(v any) error {
+		if cfg, ok := v.(*config); ok && (cfg.Port < 1 || cfg.Port > 65535) {
+			return errPortOutOfRange
+		}
+		return nil
+	}
+
+	env := dotenv.New(dotenv.WithValidator(validator))
+	env.Set("PORT", "8080")
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, 8080, cfg.Port)
+
+	env.Set("PORT", "70000")
+	err := env.Unmarshal(&config{})
+	assert.ErrorIs(t, err, errPortOutOfRange)
+	assert.EqualError(t, err, "validation failed: PORT must be between 1 and 65535")
+
+	errs := env.UnmarshalCollect(&config{})
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errPortOutOfRange)
+
+	// the validator is not run if the struct fails to unmarshal
+	env.Set("PORT", "http")
+	assert.ErrorContains(t, env.Unmarshal(&config{}), "field Port")
+}
+
+func TestToContext(t *testing.T) {
+	env := dotenv.New()
+	env.Set("TENANT", "default")
+	view := env.WithOverrides(map[string]any{"TENANT": "acme"})
+
+	ctx := dotenv.ToContext(context.Background(), view)
+	assert.Same(t, view, dotenv.FromContext(ctx))
+	assert.Equal(t, "acme", dotenv.FromContext(ctx).GetString("TENANT"))
+	assert.Equal(t, "default", env.GetString("TENANT"))
+
+	assert.Same(t, dotenv.GetDotEnv(), dotenv.FromContext(context.Background()))
+	assert.Same(t, dotenv.GetDotEnv(), dotenv.FromContext(dotenv.ToContext(context.Background(), nil)))
+}
+
+func TestState(t *testing.T) {
+	env := dotenv.New()
+	env.Set("STATE_EMPTY", "")
+	env.Set("STATE_VALUE", "value")
+	t.Setenv("STATE_ENV", "from env")
+
+	tests := []struct {
+		key            string
+		present, empty bool
+	}{
+		{"STATE_EMPTY", true, true},
+		{"STATE_VALUE", true, false},
+		{"STATE_ENV", true, false},
+		{"STATE_UNSET", false, false},
+	}
+	for _, tt := range tests {
+		present, empty := env.State(tt.key)
+		assert.Equal(t, tt.present, present, tt.key)
+		assert.Equal(t, tt.empty, empty, tt.key)
+		assert.Equal(t, tt.present, env.IsSet(tt.key), tt.key)
+	}
+}
+
+func TestGetTimeUnix(t *testing.T) {
+	env := dotenv.New()
+	env.Set("CREATED_AT", "1700000000")
+	env.Set("UPDATED_AT", 1700000000123)
+	env.Set("INVALID", "yesterday")
+
+	assert.True(t, time.Unix(1700000000, 0).Equal(env.GetTimeUnix("CREATED_AT")))
+	assert.Equal(t, int64(1700000000), env.GetTimeUnix("CREATED_AT").Unix())
+	assert.True(t, time.UnixMilli(1700000000123).Equal(env.GetTimeUnixMilli("UPDATED_AT")))
+	assert.Equal(t, int64(1700000000123), env.GetTimeUnixMilli("UPDATED_AT").UnixMilli())
+
+	assert.True(t, env.GetTimeUnix("INVALID").IsZero())
+	assert.True(t, env.GetTimeUnixMilli("INVALID").IsZero())
+	assert.True(t, env.GetTimeUnix("DOES_NOT_EXIST").IsZero())
+}
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{`--foo "bar baz" --qux`, []string{"--foo", "bar baz", "--qux"}},
+		{`-c 'echo "$HOME"'`, []string{"-c", `echo "$HOME"`}},
+		{`"say \"hi\"" \$PATH`, []string{`say "hi"`, "$PATH"}},
+		{`a\ b  c`, []string{"a b", "c"}},
+		{`--name=""  --empty ''`, []string{"--name=", "--empty", ""}},
+		{`--path="C:\dir"`, []string{`--path=C:\dir`}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			env := dotenv.New()
+			env.Set("ARGS", tt.value)
+			args, err := env.GetArgs("ARGS")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	env := dotenv.New()
+	env.Set("DOUBLE", `--foo "bar`)
+	_, err := env.GetArgs("DOUBLE")
+	assert.EqualError(t, err, `DOUBLE: unterminated " quote`)
+
+	env.Set("SINGLE", `--foo 'bar`)
+	_, err = env.GetArgs("SINGLE")
+	assert.EqualError(t, err, `SINGLE: unterminated ' quote`)
+
+	env.Set("ESCAPE", `--foo \`)
+	_, err = env.GetArgs("ESCAPE")
+	assert.EqualError(t, err, `ESCAPE: unterminated escape sequence`)
+}
+
+func TestGetStringSliceCompact(t *testing.T) {
+	env := dotenv.New()
+	env.Set("LIST", "a,,b,")
+	env.Set("SPACES", " a , ,b")
+	env.Set("EMPTY", "")
+
+	assert.Equal(t, []string{"a", "", "b", ""}, env.GetStringSlice("LIST"))
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSliceCompact("LIST"))
+
+	assert.Equal(t, []string{" a ", " ", "b"}, env.GetStringSlice("SPACES"))
+	assert.Equal(t, []string{" a ", "b"}, env.GetStringSliceCompact("SPACES"))
+
+	assert.Equal(t, []string{}, env.GetStringSlice("EMPTY"))
+	assert.Equal(t, []string{}, env.GetStringSlice("DOES_NOT_EXIST"))
+	assert.Equal(t, []string{}, env.GetStringSliceCompact("EMPTY"))
+}
+
+func TestGetMapFromPrefix(t *testing.T) {
+	env := dotenv.New()
+	env.Set("FEATURE_A", "1")
+	env.Set("FEATURE_B", "2")
+	env.SetDefault("FEATURE_C", true)
+	env.Set("FEATURES", "not matching")
+	env.Set("OTHER_FEATURE_D", "4")
+	t.Setenv("FEATURE_B", "from env")
+	t.Setenv("FEATURE_E", "5")
+
+	assert.Equal(t, map[string]string{
+		"A": "1",
+		"B": "from env",
+		"C": "true",
+		"E": "5",
+	}, env.GetMapFromPrefix("FEATURE_"))
+	assert.Empty(t, env.GetMapFromPrefix("MISSING_"))
+}
+
+func TestGetStringSliceN(t *testing.T) {
+	env := dotenv.New()
+	env.Set("HOSTS", "a,b,c")
+	env.Set("TOO_MANY", strings.Repeat("x,", 1000)+"x")
+
+	hosts, err := env.GetStringSliceN("HOSTS", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, hosts)
+
+	_, err = env.GetStringSliceN("HOSTS", 2)
+	assert.EqualError(t, err, "HOSTS: 3 elements exceed the maximum of 2")
+
+	_, err = env.GetStringSliceN("TOO_MANY", 100)
+	assert.EqualError(t, err, "TOO_MANY: 1001 elements exceed the maximum of 100")
+
+	elems, err := env.GetStringSliceN("DOES_NOT_EXIST", 0)
+	require.NoError(t, err)
+	assert.Empty(t, elems)
+}
+
+func TestGetStringSliceUnique(t *testing.T) {
+	env := dotenv.New()
+	env.Set("ALLOWED_HOSTS", "b.example.com,a.example.com,b.example.com,c.example.com,a.example.com")
+	env.Set("EMPTY", "")
+
+	assert.Equal(t, []string{"b.example.com", "a.example.com", "c.example.com"}, env.GetStringSliceUnique("ALLOWED_HOSTS"))
+	assert.Equal(t, []string{}, env.GetStringSliceUnique("EMPTY"))
+}
+
+func TestGetStringSlice_quoted(t *testing.T) {
+	env := dotenv.New()
+	env.Set("LIST", `"a","b,c","d"`)
+	env.Set("SPACED", `"a" , "b, c"`)
+	env.Set("ESCAPED", `"say \"hi\"","it""s",""`)
+	env.Set("PARTIAL", `"a",b`)
+	env.Set("UNTERMINATED", `"a,b`)
+
+	assert.Equal(t, []string{"a", "b,c", "d"}, env.GetStringSlice("LIST"))
+	assert.Equal(t, []string{"a", "b, c"}, env.GetStringSlice("SPACED"))
+	assert.Equal(t, []string{`say "hi"`, `it"s`, ""}, env.GetStringSlice("ESCAPED"))
+	// values that are not a list of quoted elements are split on commas
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSlice("PARTIAL"))
+	assert.Equal(t, []string{`"a`, "b"}, env.GetStringSlice("UNTERMINATED"))
+
+	// the quotes of the elements are kept when loaded from a file
+	require.NoError(t, env.LoadReader(strings.NewReader(`FILE_LIST="a","b,c","d"`+"\n"+`QUOTED_LIST="a,b"`)))
+	assert.Equal(t, `"a","b,c","d"`, env.GetString("FILE_LIST"))
+	assert.Equal(t, []string{"a", "b,c", "d"}, env.GetStringSlice("FILE_LIST"))
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSlice("QUOTED_LIST"))
+}
+
+func TestDotEnv_WithOverrides(t *testing.T) {
+	env := dotenv.New()
+	env.Set("TENANT", "default")
+	env.Set("REGION", "eu")
+	env.Set("TIMEOUT", "5s")
+
+	view := env.WithOverrides(map[string]any{"tenant": "acme", "TIMEOUT": 10 * time.Second})
+
+	assert.Equal(t, "acme", view.GetString("TENANT"))
+	assert.Equal(t, 10*time.Second, view.GetDuration("TIMEOUT"))
+	assert.Equal(t, "eu", view.GetString("REGION"))
+	assert.False(t, view.IsSet("UNSET"))
+
+	// the shared instance is not modified
+	assert.Equal(t, "default", env.GetString("TENANT"))
+	view.Set("REGION", "us")
+	assert.Equal(t, "us", view.GetString("REGION"))
+	assert.Equal(t, "eu", env.GetString("REGION"))
+
+	// changes to the shared instance are visible through the view
+	env.Set("LOG_LEVEL", "debug")
+	assert.Equal(t, "debug", view.GetString("LOG_LEVEL"))
+
+	// the keys and values of the view include those of the shared instance
+	assert.Equal(t, []string{"LOG_LEVEL", "REGION", "TENANT", "TIMEOUT"}, view.Keys())
+	assert.Equal(t, map[string]any{"TENANT": "acme"}, view.GetByPattern("TEN*"))
+	assert.Contains(t, view.String(), "REGION=us")
+
+	var config struct {
+		Tenant   string `env:"TENANT"`
+		LogLevel string `env:"LOG_LEVEL"`
+		Regions  map[string]struct {
+			Name string `env:"NAME"`
+		} `env:"REGION"`
+	}
+	env.Set("REGION_EU_NAME", "Europe")
+	view.Set("REGION_US_NAME", "United States")
+	require.NoError(t, view.Unmarshal(&config))
+	assert.Equal(t, "acme", config.Tenant)
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "Europe", config.Regions["eu"].Name)
+	assert.Equal(t, "United States", config.Regions["us"].Name)
+
+	// the view is frozen with the shared instance
+	env.Freeze()
+	assert.PanicsWithValue(t, dotenv.ErrFrozen, func() { view.Set("TENANT", "other") })
+}
+
+func TestDotEnv_AllowIncludes(t *testing.T) {
+	env := dotenv.New()
+	env.AllowIncludes(true)
+	require.NoError(t, env.Load("fixtures/include/app.env"))
+
+	assert.Equal(t, "localhost", env.GetString("HOST"))
+	assert.Equal(t, 9090, env.GetInt("PORT"))
+	assert.Equal(t, "false", env.GetString("DEBUG"))
+
+	err := env.Load("fixtures/include/cycle_a.env")
+	assert.EqualError(t, err, "include cycle detected: fixtures/include/cycle_a.env -> fixtures/include/cycle_b.env -> fixtures/include/cycle_a.env")
+
+	// directives inside quoted values are part of the value
+	require.NoError(t, env.Load("fixtures/include/quoted.env"))
+	assert.Equal(t, "welcome\n#include ./base.env\nto the app", env.GetString("BANNER"))
+
+	// errors report the lines of the original files
+	err = env.Load("fixtures/include/invalid_after.env")
+	assert.EqualError(t, err, "line 3: key cannot contain spaces")
+	err = env.Load("fixtures/include/invalid_parent.env")
+	assert.EqualError(t, err, "fixtures/include/invalid_included.env: line 3: key cannot contain spaces")
+	var parseErr *dotenv.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 3, parseErr.Line)
+
+	// includes are comments unless allowed
+	env = dotenv.New()
+	require.NoError(t, env.Load("fixtures/include/app.env"))
+	assert.False(t, env.IsSet("HOST"))
+	assert.Equal(t, 9090, env.GetInt("PORT"))
+}
+
+func TestGetSecret(t *testing.T) {
+	env := dotenv.New()
+	env.Set("API_TOKEN", "s3cr3t")
+
+	secret, destroy := env.GetSecret("API_TOKEN")
+	assert.Equal(t, []byte("s3cr3t"), secret)
+
+	destroy()
+	assert.Equal(t, make([]byte, 6), secret)
+	// the config value is not affected
+	assert.Equal(t, "s3cr3t", env.GetString("API_TOKEN"))
+
+	secret, destroy = env.GetSecret("UNSET_TOKEN")
+	assert.Empty(t, secret)
+	destroy()
+}
+
+type semver struct {
+	Major, Minor, Patch int
+}
+
+func parseSemver(value string) (any, error) {
+	var v semver
+	if _, err := fmt.Sscanf(value, "v%d.%d.%d", &v.Major, &v.Minor, &v.Patch); err != nil {
+		return nil, fmt.Errorf("invalid version %q", value)
+	}
+	return v, nil
+}
+
+func TestRegisterTypeConverter(t *testing.T) {
+	type config struct {
+		Version semver `env:"VERSION"`
+		MinVer  semver `env:"MIN_VERSION" default:"v1.0.0"`
+		Name    string `env:"NAME"`
+	}
+
+	env := dotenv.New()
+	env.RegisterTypeConverter(reflect.TypeOf(semver{}), parseSemver)
+	env.Set("VERSION", "v1.2.3")
+	env.Set("NAME", "app")
+
+	cfg := config{}
+	require.NoError(t, env.Unmarshal(&cfg))
+	assert.Equal(t, semver{1, 2, 3}, cfg.Version)
+	assert.Equal(t, semver{1, 0, 0}, cfg.MinVer)
+	assert.Equal(t, "app", cfg.Name)
+
+	// the converters are used by views
+	cfg = config{}
+	require.NoError(t, env.WithOverrides(map[string]any{"VERSION": "v2.0.0"}).Unmarshal(&cfg))
+	assert.Equal(t, semver{2, 0, 0}, cfg.Version)
+	assert.Equal(t, semver{1, 0, 0}, cfg.MinVer)
+
+	env.Set("VERSION", "latest")
+	assert.EqualError(t, env.Unmarshal(&config{}), `field Version: invalid version "latest"`)
+
+	env.RegisterTypeConverter(reflect.TypeOf(semver{}), func(string) (any, error) { return "v1", nil })
+	env.Set("VERSION", "v1.2.3")
+	assert.EqualError(t, env.Unmarshal(&config{}), "field Version: converter returned string, expected dotenv_test.semver")
+}
+
+func TestGetInferred(t *testing.T) {
+	tests := []struct {
+		value string
+		want  any
+	}{
+		{"true", true},
+		{"FALSE", false},
+		{"yes", "yes"},
+		{"42", 42},
+		{"-7", -7},
+		{"+3", 3},
+		{"0", 0},
+		{"0755", "0755"},
+		{"-01", "-01"},
+		{"1.0", 1.0},
+		{"0.5", 0.5},
+		{".5", 0.5},
+		{"-2.25", -2.25},
+		{"1e3", "1e3"},
+		{"1.2.3", "1.2.3"},
+		{"99999999999999999999", "99999999999999999999"},
+		{"localhost", "localhost"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			env := dotenv.New()
+			env.Set("VALUE", tt.value)
+			assert.Equal(t, tt.want, env.GetInferred("VALUE"))
+		})
+	}
+
+	assert.Nil(t, dotenv.New().GetInferred("UNSET"))
+}
+
+func TestDotEnv_LoadLargeFile(t *testing.T) {
+	info, err := os.Stat("fixtures/large.env")
+	require.NoError(t, err)
+
+	var progress []int64
+	env := dotenv.New()
+	err = env.LoadLargeFile("fixtures/large.env", func(bytesRead int64) {
+		progress = append(progress, bytesRead)
+	})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, progress)
+	assert.Equal(t, info.Size(), progress[len(progress)-1])
+
+	expected := dotenv.New()
+	require.NoError(t, expected.Load("fixtures/large.env"))
+	assert.Equal(t, expected.Keys(), env.Keys())
+	for _, key := range expected.Keys() {
+		assert.Equal(t, expected.GetString(key), env.GetString(key), key)
+	}
+	assert.Equal(t, "fixtures/large.env", env.ConfigFileUsed())
+
+	assert.ErrorIs(t, env.LoadLargeFile("fixtures/unknown.env", nil), os.ErrNotExist)
+}
+
+func TestDotEnv_Equal(t *testing.T) {
+	a := dotenv.New()
+	a.Set("PORT", 8080)
+	a.Set("HOST", "localhost")
+
+	b := dotenv.New()
+	b.NormalizeKeys(true)
+	b.Set("host", "localhost")
+	b.Set("PORT", "8080")
+
+	assert.True(t, a.Equal(b))
+	assert.True(t, b.Equal(a))
+
+	b.Set("PORT", "9090")
+	assert.False(t, a.Equal(b))
+
+	b.Set("PORT", "8080")
+	b.Set("DEBUG", true)
+	assert.False(t, a.Equal(b))
+	assert.False(t, b.Equal(a))
+
+	assert.False(t, a.Equal(nil))
+}
+
+func TestGetStringMapInt(t *testing.T) {
+	env := dotenv.New()
+	env.Set("WEIGHTS", "a:1, b:2")
+	env.Set("INVALID_WEIGHTS", "a:1,b:two")
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, env.GetStringMapInt("WEIGHTS"))
+	assert.Equal(t, map[string]int{"a": 1}, env.GetStringMapInt("INVALID_WEIGHTS"))
+
+	m, err := env.GetStringMapIntE("WEIGHTS")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, m)
+
+	_, err = env.GetStringMapIntE("INVALID_WEIGHTS")
+	assert.ErrorContains(t, err, `INVALID_WEIGHTS: invalid map value for "b"`)
+}
+
+func TestGetStringMapBool(t *testing.T) {
+	env := dotenv.New()
+	env.Set("FEATURES", "beta:true,legacy:false,new-ui:1")
+	env.Set("INVALID_FEATURES", "beta:true,legacy:maybe")
+
+	assert.Equal(t, map[string]bool{"beta": true, "legacy": false, "new-ui": true}, env.GetStringMapBool("FEATURES"))
+	assert.Equal(t, map[string]bool{"beta": true}, env.GetStringMapBool("INVALID_FEATURES"))
+
+	_, err := env.GetStringMapBoolE("INVALID_FEATURES")
+	assert.ErrorContains(t, err, `INVALID_FEATURES: invalid map value for "legacy"`)
+
+	_, err = env.GetStringMapBoolE("UNSEPARATED")
+	assert.NoError(t, err)
+	env.Set("UNSEPARATED", "beta")
+	_, err = env.GetStringMapBoolE("UNSEPARATED")
+	assert.EqualError(t, err, `UNSEPARATED: invalid map entry "beta": missing separator ':'`)
+}
+
+func TestDotEnv_PreserveKeyCase(t *testing.T) {
+	env := dotenv.New()
+	env.PreserveKeyCase(true)
+
+	env.Set("dbHost", "localhost")
+	assert.Equal(t, []string{"dbHost"}, env.Keys())
+	assert.Equal(t, "localhost", env.GetString("dbHost"))
+	assert.Equal(t, "localhost", env.GetString("DBHOST"))
+
+	// setting a key with a different case updates the existing key
+	env.Set("DBHOST", "db.internal")
+	assert.Equal(t, []string{"dbHost"}, env.Keys())
+	assert.Equal(t, "db.internal", env.GetString("dbhost"))
+
+	// keys are decoded as written
+	require.NoError(t, env.LoadReader(strings.NewReader("logLevel=debug\nLOGLEVEL_OVERRIDE=warn")))
+	assert.Equal(t, []string{"LOGLEVEL_OVERRIDE", "dbHost", "logLevel"}, env.Keys())
+	assert.Equal(t, "debug", env.GetString("LOGLEVEL"))
+
+	// environment variables are matched as written or uppercased
+	t.Setenv("LOGLEVEL", "error")
+	assert.Equal(t, "error", env.GetString("logLevel"))
+
+	// loaded keys with a different case update the existing key
+	require.NoError(t, env.LoadReader(strings.NewReader("DBHOST=from-reader")))
+	assert.Equal(t, []string{"LOGLEVEL_OVERRIDE", "dbHost", "logLevel"}, env.Keys())
+	assert.Equal(t, "from-reader", env.GetString("dbhost"))
+
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("DBHOST=from-file\n"), 0600))
+	require.NoError(t, env.Load(file))
+	assert.Equal(t, []string{"LOGLEVEL_OVERRIDE", "dbHost", "logLevel"}, env.Keys())
+	assert.Equal(t, "from-file", env.GetString("DBHOST"))
+
+	// the option is applied to a decoder set later
+	env.SetDecoder(&dotenv.DefaultDecoder{})
+	require.NoError(t, env.LoadReader(strings.NewReader("apiUrl=http://localhost")))
+	assert.Contains(t, env.Keys(), "apiUrl")
+
+	env = dotenv.New(dotenv.WithDecoder(&dotenv.DefaultDecoder{}))
+	env.PreserveKeyCase(true)
+	require.NoError(t, env.LoadReader(strings.NewReader("apiUrl=http://localhost")))
+	assert.Equal(t, []string{"apiUrl"}, env.Keys())
+
+	// without the option keys are uppercased
+	env = dotenv.New()
+	env.Set("dbHost", "localhost")
+	assert.Equal(t, []string{"DBHOST"}, env.Keys())
+	assert.Equal(t, "localhost", env.GetString("dbhost"))
+}
+
+func TestDotEnv_ExpandString(t *testing.T) {
+	t.Setenv("EXPAND_USER", "admin")
+
+	env := dotenv.New()
+	env.Set("HOST", "localhost")
+	env.Set("PORT", 8080)
+	env.Set("EMPTY", "")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"http://$HOST:${PORT}/api", "http://localhost:8080/api"},
+		{"user=${EXPAND_USER}", "user=admin"},
+		{"${host}", "localhost"},
+		{"[$UNSET]", "[]"},
+		{"[${UNSET}]", "[]"},
+		{"${UNSET:-fallback}", "fallback"},
+		{"${UNSET-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${PORT:-9090}", "8080"},
+		{"${UNSET:-}", ""},
+		{`\$HOST costs $$5`, "$HOST costs $$5"},
+		{"no references", "no references"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, env.ExpandString(tt.in))
+		})
+	}
+}
+
+func TestDotEnv_SetFileHeader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.SetFileHeader("Generated by myapp", "# do not edit")
+	env.Set("PORT", 8080)
+	require.NoError(t, env.Save())
+
+	expected := "# Generated by myapp\n# do not edit\nPORT=8080\n"
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+
+	// the header is ignored on load and written once on save
+	loaded := dotenv.New()
+	loaded.SetConfigFile(file)
+	loaded.SetFileHeader("Generated by myapp", "# do not edit")
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, []string{"PORT"}, loaded.Keys())
+	require.NoError(t, loaded.Save())
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
+
+func TestDotEnv_SaveGroupByPrefix(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.SetEncoder(&dotenv.DefaultEncoder{GroupByPrefix: true})
+	env.Set("DB_HOST", "localhost")
+	env.Set("CACHE_URL", "redis://localhost")
+	env.Set("DB_PORT", 5432)
+	env.Set("DEBUG", true)
+	env.Set("CACHE_TTL", "5m")
+	require.NoError(t, env.Save())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `DEBUG=true
+
+# CACHE
+CACHE_TTL=5m
+CACHE_URL=redis://localhost
+
+# DB
+DB_HOST=localhost
+DB_PORT=5432
+`, string(data))
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	assert.True(t, env.Equal(loaded))
+}
+
+func TestDotEnv_SaveQuoteAll(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.SetEncoder(&dotenv.DefaultEncoder{QuoteAll: true})
+	env.Set("HOST", "localhost")
+	env.Set("PORT", 5432)
+	env.Set("EMPTY", "")
+	env.Set("GREETING", `say "hi"`)
+	env.Set("MULTILINE", "first\nsecond")
+	require.NoError(t, env.Save())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `EMPTY=""
+GREETING="say \"hi\""
+HOST="localhost"
+MULTILINE="first\nsecond"
+PORT="5432"
+`, string(data))
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	assert.True(t, env.Equal(loaded))
+}
+
+func TestDotEnv_LoadProfile(t *testing.T) {
+	env := dotenv.New()
+	require.NoError(t, env.LoadProfile("fixtures/profiles.env", "dev"))
+
+	assert.Equal(t, []string{"APP_NAME", "BANNER", "DB_HOST", "LOG_LEVEL"}, env.Keys())
+	assert.Equal(t, "myapp", env.GetString("APP_NAME"))
+	assert.Equal(t, "localhost", env.GetString("DB_HOST"))
+	assert.Equal(t, "debug", env.GetString("LOG_LEVEL"))
+	assert.Equal(t, "\n  development\n", env.GetString("BANNER"))
+
+	env = dotenv.New()
+	require.NoError(t, env.LoadProfile("fixtures/profiles.env", "prod"))
+
+	assert.Equal(t, []string{"APP_NAME", "DB_HOST", "LOG_LEVEL"}, env.Keys())
+	assert.Equal(t, "db.internal", env.GetString("DB_HOST"))
+	assert.Equal(t, "info", env.GetString("LOG_LEVEL"))
+
+	// the profile is kept on reload
+	require.NoError(t, env.Reload())
+	assert.Equal(t, "db.internal", env.GetString("DB_HOST"))
+
+	assert.ErrorIs(t, env.LoadProfile("fixtures/unknown.env", "prod"), os.ErrNotExist)
+
+	// the raw values of the profile are kept
+	env = dotenv.New()
+	env.SetDecoder(&dotenv.DefaultDecoder{KeepRawValues: true})
+	require.NoError(t, env.LoadProfile("fixtures/profiles.env", "dev"))
+	raw, ok := env.RawValue("DB_HOST")
+	assert.True(t, ok)
+	assert.Equal(t, "localhost", raw)
+}
+
+func TestUnMarshal_unsupportedFields(t *testing.T) {
+	type withChan struct {
+		Events chan int `env:"EVENTS"`
+	}
+	type withFunc struct {
+		Handler func() `env:"HANDLER" default:"noop"`
+	}
+	type withUnexported struct {
+		Name    string `env:"NAME"`
+		counter int    `env:"COUNTER"`
+	}
+
+	env := dotenv.New()
+	env.Set("EVENTS", "10")
+	env.Set("NAME", "app")
+	env.Set("COUNTER", "1")
+
+	assert.NotPanics(t, func() {
+		assert.EqualError(t, env.Unmarshal(&withChan{}), "field Events: unsupported type chan int")
+		assert.EqualError(t, env.Unmarshal(&withFunc{}), "field Handler: unsupported type func()")
+		// unsupported fields are reported when their key is not set
+		assert.EqualError(t, dotenv.New().Unmarshal(&withChan{}), "field Events: unsupported type chan int")
+
+		cfg := withUnexported{}
+		assert.NoError(t, env.Unmarshal(&cfg))
+		assert.Equal(t, "app", cfg.Name)
+		assert.Zero(t, cfg.counter)
+
+		assert.EqualError(t, env.Unmarshal(withChan{}), "expected a pointer to a struct, got dotenv_test.withChan")
+		assert.EqualError(t, env.Unmarshal((*withChan)(nil)), "expected a pointer to a struct, got *dotenv_test.withChan")
+		n := 1
+		assert.EqualError(t, env.Unmarshal(&n), "expected a struct, got *int")
+	})
+}
+
+func TestGetStringSlice_json(t *testing.T) {
+	env := dotenv.New()
+	env.Set("TAGS", ` ["a", "b, c", "d\"e"] `)
+	env.Set("EMPTY_TAGS", "[]")
+	env.Set("PLAIN_TAGS", "a,b,c")
+	env.Set("NUMBERS", "[1,2]")
+	env.Set("MALFORMED_TAGS", `["a",b]`)
+
+	assert.Equal(t, []string{"a", "b, c", `d"e`}, env.GetStringSlice("TAGS"))
+	assert.Equal(t, []string{}, env.GetStringSlice("EMPTY_TAGS"))
+	assert.Equal(t, []string{"a", "b", "c"}, env.GetStringSlice("PLAIN_TAGS"))
+	// values that are not a JSON array of strings fall back to comma splitting
+	assert.Equal(t, []string{"1", "2"}, env.GetStringSlice("NUMBERS"))
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSlice("MALFORMED_TAGS"))
+}
+
+func TestDotEnv_SetWithTTL(t *testing.T) {
+	t.Setenv("TTL_FROM_ENV", "env")
+
+	env := dotenv.New()
+	env.Set("FEATURE_CHECKOUT", "false")
+
+	env.SetWithTTL("FEATURE_CHECKOUT", "true", 50*time.Millisecond)
+	env.SetWithTTL("TTL_FROM_ENV", "override", 50*time.Millisecond)
+	env.SetWithTTL("TTL_UNSET", "temporary", 50*time.Millisecond)
+
+	assert.True(t, env.GetBool("FEATURE_CHECKOUT"))
+	assert.Equal(t, "override", env.GetString("TTL_FROM_ENV"))
+	assert.Equal(t, "temporary", env.GetString("TTL_UNSET"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.False(t, env.GetBool("FEATURE_CHECKOUT"))
+	assert.Equal(t, "env", env.GetString("TTL_FROM_ENV"))
+	assert.False(t, env.IsSet("TTL_UNSET"))
+}
+
+func TestGetStringSlice_quotedElements(t *testing.T) {
+	env := dotenv.New()
+	env.Set("NAMES", `'a','b'`)
+	env.Set("MIXED", `'a',"b",c`)
+	env.Set("BRACKETED", `['x','y']`)
+	env.Set("PIPED", `'a' | "b"`)
+	env.Set("PORTS", `'80','443'`)
+	env.Set("UNMATCHED", `'a,b"`)
+
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSlice("NAMES"))
+	assert.Equal(t, []string{"a", "b", "c"}, env.GetStringSlice("MIXED"))
+	assert.Equal(t, []string{"x", "y"}, env.GetStringSlice("BRACKETED"))
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSliceWithSep("PIPED", "|"))
+	assert.Equal(t, []int{80, 443}, env.GetIntSlice("PORTS"))
+	assert.Equal(t, []string{`'a`, `b"`}, env.GetStringSlice("UNMATCHED"))
+
+	require.NoError(t, env.LoadReader(strings.NewReader(`FILE_NAMES='a','b'`)))
+	assert.Equal(t, []string{"a", "b"}, env.GetStringSlice("FILE_NAMES"))
+}
+
+func TestDotEnv_Merge(t *testing.T) {
+	newBase := func() *dotenv.DotEnv {
+		base := dotenv.New()
+		base.Set("HOST", "localhost")
+		base.Set("PORT", 8080)
+		return base
+	}
+
+	overlay := dotenv.New()
+	overlay.Set("PORT", 9090)
+	overlay.Set("DEBUG", true)
+
+	base := newBase()
+	base.Merge(overlay, false)
+	assert.Equal(t, []string{"DEBUG", "HOST", "PORT"}, base.Keys())
+	assert.Equal(t, 8080, base.GetInt("PORT"))
+	assert.True(t, base.GetBool("DEBUG"))
+
+	base = newBase()
+	base.Merge(overlay, true)
+	assert.Equal(t, []string{"DEBUG", "HOST", "PORT"}, base.Keys())
+	assert.Equal(t, 9090, base.GetInt("PORT"))
+	assert.Equal(t, "localhost", base.GetString("HOST"))
+
+	// the merged instance is not modified
+	assert.Equal(t, []string{"DEBUG", "PORT"}, overlay.Keys())
+
+	// merging instances into each other concurrently doesn't deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); base.Merge(overlay, true) }()
+		go func() { defer wg.Done(); overlay.Merge(base, false) }()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"DEBUG", "HOST", "PORT"}, overlay.Keys())
+}
+
+func TestGetStringMapString_roundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	labels := map[string]string{"team": "core", "tier": "backend"}
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	env.Set("LABELS", labels)
+	env.Set("LIMITS", map[string]any{"cpu": 2})
+
+	assert.Equal(t, labels, env.GetStringMapString("LABELS"))
+	assert.Equal(t, map[string]string{"cpu": "2"}, env.GetStringMapString("LIMITS"))
+	assert.Equal(t, map[string]int{"cpu": 2}, env.GetStringMapInt("LIMITS"))
+
+	// the E variants read maps set with Set too
+	env.Set("WEIGHTS", map[string]string{"a": "1"})
+	env.Set("FLAGS", map[string]any{"beta": true})
+	gotStrings, err := env.GetStringMapStringE("LABELS")
+	require.NoError(t, err)
+	assert.Equal(t, labels, gotStrings)
+	gotInts, err := env.GetStringMapIntE("WEIGHTS")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, gotInts)
+	gotBools, err := env.GetStringMapBoolE("FLAGS")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"beta": true}, gotBools)
+
+	require.NoError(t, env.Save())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "FLAGS=beta:true\nLABELS=team:core,tier:backend\nLIMITS=cpu:2\nWEIGHTS=a:1\n", string(data))
+
+	loaded := dotenv.New()
+	require.NoError(t, loaded.Load(file))
+	assert.Equal(t, labels, loaded.GetStringMapString("LABELS"))
+}
+
+func TestLoadOnce(t *testing.T) {
+	env := dotenv.New()
+	restore := dotenv.ReplaceDefault(env)
+	defer restore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, dotenv.LoadOnce("fixtures/plain.env"))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(1), env.Stats().Loads)
+	assert.Equal(t, "3", env.GetString("OPTION_C"))
+
+	// the first result is returned by subsequent calls
+	assert.NoError(t, dotenv.LoadOnce("fixtures/unknown.env"))
+	assert.Equal(t, uint64(1), env.Stats().Loads)
+
+	failing := dotenv.New()
+	assert.ErrorIs(t, failing.LoadOnce("fixtures/unknown.env"), os.ErrNotExist)
+	assert.ErrorIs(t, failing.LoadOnce("fixtures/plain.env"), os.ErrNotExist)
+	assert.False(t, failing.IsSet("OPTION_C"))
+}
+
+func TestGetIntStrict(t *testing.T) {
+	env := dotenv.New()
+	env.Set("COUNT", " 42 ")
+	env.Set("NEGATIVE", -7)
+	env.Set("OVERFLOW", "99999999999999999999")
+	env.Set("UNDERFLOW", "-99999999999999999999")
+	env.Set("INVALID", "4.2")
+
+	i, err := env.GetIntStrict("COUNT")
+	require.NoError(t, err)
+	assert.Equal(t, 42, i)
+
+	i, err = env.GetIntStrict("NEGATIVE")
+	require.NoError(t, err)
+	assert.Equal(t, -7, i)
+
+	_, err = env.GetIntStrict("OVERFLOW")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	assert.ErrorContains(t, err, "OVERFLOW: ")
+
+	_, err = env.GetIntStrict("UNDERFLOW")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+
+	_, err = env.GetIntStrict("INVALID")
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+
+	_, err = env.GetIntStrict("UNSET")
+	assert.EqualError(t, err, "UNSET: key is not set")
+}
+
+func TestLoad_stdin(t *testing.T) {
+	env := dotenv.New()
+	env.SetStdin(strings.NewReader("HOST=localhost\nPORT=8080\n"))
+	env.SetConfigFile("-")
+	require.NoError(t, env.Load())
+
+	assert.Equal(t, "localhost", env.GetString("HOST"))
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+	assert.Equal(t, "-", env.ConfigFileUsed())
+
+	// stdin can be combined with other files
+	env = dotenv.New()
+	env.SetStdin(strings.NewReader("OPTION_C=fromStdin"))
+	require.NoError(t, env.Load("fixtures/plain.env", dotenv.StdinConfigFile))
+	assert.Equal(t, "fromStdin", env.GetString("OPTION_C"))
+	assert.Equal(t, "4", env.GetString("OPTION_D"))
+
+	// stdin is not read again on reload
+	env.Set("OPTION_D", "changed")
+	require.NoError(t, env.Reload())
+	assert.Equal(t, "fromStdin", env.GetString("OPTION_C"))
+	assert.Equal(t, "4", env.GetString("OPTION_D"))
+}
@@ -0,0 +1,119 @@
+package dotenv
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Encoder encodes a map of configuration values into the contents of an env file.
+type Encoder interface {
+	Encode(v map[string]any) ([]byte, error)
+}
+
+// DefaultEncoder is the default encoder used by the library.
+// Keys are written in sorted order and values are double-quoted
+// and escaped when they cannot be represented unquoted.
+type DefaultEncoder struct {
+	// GroupByPrefix groups the keys by their prefix up to the first underscore,
+	// e.g. DB_HOST and DB_PORT, separating the groups with a blank line
+	// and labelling each with a comment, e.g. # DB.
+	// Keys without an underscore are written first, without a label.
+	GroupByPrefix bool
+
+	// QuoteAll double-quotes and escapes every value, including empty values,
+	// instead of only those that cannot be represented unquoted,
+	// for compatibility with strict parsers.
+	QuoteAll bool
+}
+
+// Encode encodes v into the contents of an env file.
+func (enc *DefaultEncoder) Encode(v map[string]any) ([]byte, error) {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if enc.GroupByPrefix {
+		// keys without a prefix go first
+		sort.SliceStable(keys, func(i, j int) bool {
+			return !strings.Contains(keys[i], "_") && strings.Contains(keys[j], "_")
+		})
+	}
+
+	var b strings.Builder
+	group := ""
+	for i, key := range keys {
+		if enc.GroupByPrefix {
+			if prefix, _, ok := strings.Cut(key, "_"); ok && (i == 0 || prefix != group) {
+				if i > 0 {
+					b.WriteByte('\n')
+				}
+				b.WriteString("# " + prefix + "\n")
+				group = prefix
+			}
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(encodeValue(formatValue(v[key]), enc.QuoteAll))
+		b.WriteByte('\n')
+	}
+
+	return []byte(b.String()), nil
+}
+
+// formatValue converts a config value to a string.
+// Maps are written as sorted k:v,k:v entries, which GetStringMapString reads back.
+func formatValue(value any) string {
+	var m map[string]string
+	switch val := value.(type) {
+	case map[string]string:
+		m = val
+	case map[string]any:
+		m = cast.ToStringMapString(val)
+	default:
+		return cast.ToString(value)
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = key + ":" + m[key]
+	}
+	return strings.Join(entries, ",")
+}
+
+// encodeValue returns value in a form the DefaultDecoder reads back unchanged.
+// The value is only quoted if needed, unless quoteAll is true.
+func encodeValue(value string, quoteAll bool) string {
+	if !quoteAll && !strings.ContainsAny(value, " \t\n\r#\"'`\\") {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte(prefixDoubleQuote)
+	for _, c := range value {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte(prefixDoubleQuote)
+
+	return b.String()
+}
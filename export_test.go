@@ -0,0 +1,8 @@
+package dotenv
+
+import "io"
+
+// SetStdin replaces the reader the "-" config file is read from.
+func (e *DotEnv) SetStdin(r io.Reader) {
+	e.stdin = r
+}
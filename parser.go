@@ -1,22 +1,45 @@
 package dotenv
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
+	"text/template"
 )
 
 const (
 	prefixSingleQuote = '\''
 	prefixDoubleQuote = '"'
+	prefixBacktick    = '`'
 )
 
 var (
 	escapeRegex        = regexp.MustCompile(`\\.`)
 	unescapeCharsRegex = regexp.MustCompile(`\\([^$])`)
+	expandVarRegex     = regexp.MustCompile(`(\\)?(\$)(\()?\{?([A-Z0-9_]+)?\}?`)
 )
 
+// ParseError is returned by the DefaultDecoder when the contents of an env file are invalid.
+type ParseError struct {
+	// Line is the line number the error occurred on.
+	Line int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // Decoder decodes the contents of an env file into a map.
 type Decoder interface {
 	Decode(b []byte, v map[string]any) error
@@ -24,26 +47,117 @@ type Decoder interface {
 
 // DefaultDecoder is the default decoder used by the library.
 type DefaultDecoder struct {
-	line int
+	// AppendSeparator joins the existing and new value of a key assigned with
+	// the append operator, e.g. PATH+=/extra. Defaults to a comma.
+	AppendSeparator string
+
+	// TrimValues trims surrounding whitespace from double-quoted values.
+	// Unquoted values are always trimmed and single-quoted and backtick-quoted values are kept as written.
+	TrimValues bool
+
+	// OnEntry, if set, is called for every entry as it's decoded
+	// with the line number the entry starts on.
+	OnEntry func(key, value string, line int)
+
+	// KeepRawValues retains the values as written in the file, before
+	// unquoting and unescaping, so they can be retrieved with RawValue.
+	KeepRawValues bool
+
+	// GodotenvCompat matches the parsing semantics of github.com/joho/godotenv:
+	//   - exported keys are stored like any other key instead of being set in the environment
+	//   - inline comments in unquoted values must be preceded by whitespace
+	//   - $VAR and ${VAR} are expanded in unquoted and double-quoted values
+	GodotenvCompat bool
+
+	// StrictEscapes returns a ParseError for unknown escape sequences in double-quoted values,
+	// e.g. \q. Known escape sequences are \n, \r, \\, \" and \$.
+	// By default, the backslash of an unknown escape sequence is removed.
+	StrictEscapes bool
+
+	// TemplateValues renders unquoted and double-quoted values as text/template templates
+	// with the values decoded so far, e.g. URL={{.HOST}}:{{.PORT}}.
+	// Referencing an undefined key returns a ParseError.
+	TemplateValues bool
+
+	// BareKeysAsTrue decodes a line with a key but no separator, e.g. DEBUG, as KEY=true,
+	// so presence-only flags can be read with GetBool.
+	// By default, such a key is decoded with an empty value.
+	BareKeysAsTrue bool
+
+	// ContinueOnError skips the lines that fail to decode instead of stopping at the first one.
+	// The valid entries are decoded and the errors of all the invalid lines are returned
+	// at the end, joined with errors.Join.
+	ContinueOnError bool
+
+	// PreserveKeyCase keeps keys as written instead of converting them to uppercase.
+	PreserveKeyCase bool
+
+	// Profile enables [section] headers and selects the section to decode.
+	// Only the entries before the first header, which are shared by all profiles,
+	// and the entries of the [Profile] section are decoded.
+	// By default, section headers are not recognized.
+	Profile string
+
+	line      int
+	rawValues map[string]string
+}
+
+// entry is a key/value pair as written in an env file.
+type entry struct {
+	key      string
+	value    string
+	quote    byte
+	isAppend bool
+	line     int
+	section  string
 }
 
 // Decode decodes the contents of b into v.
 func (d *DefaultDecoder) Decode(b []byte, v map[string]any) error {
-	data := string(b)
-	lines := strings.Split(data, "\n")
+	return d.DecodeReader(bytes.NewReader(b), v)
+}
+
+// DecodeReader decodes the contents of r into v, reading it line by line
+// so the whole content is never held in memory at once.
+func (d *DefaultDecoder) DecodeReader(r io.Reader, v map[string]any) error {
+	br := bufio.NewReader(r)
 
-	var curKey, curVal string
-	var curQuote byte
+	// cur is the entry of the quoted value block being read, if any
+	var cur *entry
+	// section is the [section] the lines being read belong to, if Profile is set
+	var section string
+	// errs holds the errors of the skipped lines if ContinueOnError is enabled
+	var errs []error
+	fail := func(err error) error {
+		if !d.ContinueOnError {
+			return err
+		}
+		errs = append(errs, err)
+		return nil
+	}
+
+	d.line = 0
+	for eof := false; !eof; {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
 
-	for _, line := range lines {
 		d.line++
-		if curQuote == 0 {
+		if cur == nil {
 			// not in a quoted value block
 			line = strings.TrimSpace(line)
 			// Skip empty lines and comments
 			if line == "" || line[0] == '#' {
 				continue
 			}
+			if d.Profile != "" && len(line) > 1 && line[0] == '[' && line[len(line)-1] == ']' {
+				section = strings.TrimSpace(line[1 : len(line)-1])
+				continue
+			}
 
 			// find the first occurrence of an equal sign or colon
 			key, val, ok := strings.Cut(line, "=")
@@ -51,63 +165,237 @@ func (d *DefaultDecoder) Decode(b []byte, v map[string]any) error {
 				key, val, ok = strings.Cut(line, ":")
 				// TODO: support inherited variables
 			}
+			if !ok && d.BareKeysAsTrue {
+				val = "true"
+			}
 			key = strings.TrimSpace(key)
+			// check for the append operator, e.g. PATH+=/extra
+			isAppend := false
+			if strings.HasSuffix(key, "+") {
+				isAppend = true
+				key = strings.TrimSpace(strings.TrimSuffix(key, "+"))
+			}
 			if !strings.HasPrefix(key, "export ") && strings.Contains(key, " ") {
-				return fmt.Errorf("line %d: key cannot contain spaces", d.line)
+				if err := fail(&ParseError{Line: d.line, Err: errors.New("key cannot contain spaces")}); err != nil {
+					return err
+				}
+				continue
 			}
 
 			val = strings.TrimSpace(val)
 			// check if the value is quoted
 			quote, isQuoted := isPrefixQuoted(val)
+			ent := &entry{key: key, value: val, quote: quote, isAppend: isAppend, line: d.line, section: section}
 			if isQuoted {
 				// get the value without the quotes
 				// if the value is quoted, check if it's a multi-line value
 				idx := d.findTerminator(val[1:], quote)
 				if idx == -1 {
 					// if the value is not terminated, continue to the next line
-					curKey = key
-					curVal = val
-					curQuote = quote
+					cur = ent
 					continue
 				}
 			}
 
-			val = parseValue(val)
-			addEnv(key, val, v)
+			if err := d.addEnv(ent, v); err != nil {
+				if err := fail(err); err != nil {
+					return err
+				}
+			}
 			continue
 		}
 
 		// in a quoted value block
-		curVal += "\n" + line
-		if d.findTerminator(line, curQuote) == -1 {
+		cur.value += "\n" + line
+		if d.findTerminator(line, cur.quote) == -1 {
 			continue
 		}
 
 		// value is terminated, parse and add to the environment
-		curVal = parseValue(curVal)
-		addEnv(curKey, curVal, v)
-		curKey, curVal, curQuote = "", "", 0
+		if err := d.addEnv(cur, v); err != nil {
+			if err := fail(err); err != nil {
+				return err
+			}
+		}
+		cur = nil
+	}
+
+	if cur != nil {
+		if err := fail(&ParseError{Line: d.line, Err: errors.New("unterminated quoted value")}); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addEnv parses the value of the entry and adds it to the environment.
+// If the entry uses the append operator, the value is appended to the existing value of the key, if any.
+func (d *DefaultDecoder) addEnv(ent *entry, v map[string]any) error {
+	if ent.section != "" && ent.section != d.Profile {
+		// the entry belongs to another profile
+		return nil
+	}
+
+	if d.StrictEscapes && ent.quote == prefixDoubleQuote {
+		if err := d.checkEscapes(ent.value); err != nil {
+			return &ParseError{Line: ent.line, Err: err}
+		}
+	}
+
+	key := ent.key
+	var value string
+	if d.GodotenvCompat {
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		value = d.parseGodotenvValue(ent.value, ent.quote, v)
+	} else {
+		value = parseValue(ent.value)
+	}
+	value = d.trimValue(value, ent.quote)
+
+	if d.TemplateValues && ent.quote != prefixSingleQuote && ent.quote != prefixBacktick {
+		var err error
+		value, err = renderTemplate(value, v)
+		if err != nil {
+			return &ParseError{Line: ent.line, Err: err}
+		}
+	}
+
+	if strings.HasPrefix(key, "export ") {
+		key = key[7:]
+		if d.OnEntry != nil {
+			d.OnEntry(key, value, ent.line)
+		}
+		if existing, ok := os.LookupEnv(key); ok && ent.isAppend {
+			value = d.appendValue(existing, value)
+		}
+		_ = os.Setenv(key, value)
+		return nil
+	}
+
+	if !d.PreserveKeyCase {
+		key = strings.ToUpper(key)
 	}
+	if d.OnEntry != nil {
+		d.OnEntry(key, value, ent.line)
+	}
+	if existing, ok := v[key]; ok && ent.isAppend {
+		value = d.appendValue(fmt.Sprint(existing), value)
+	}
+	v[key] = value
+
+	if d.KeepRawValues {
+		if d.rawValues == nil {
+			d.rawValues = make(map[string]string)
+		}
+		d.rawValues[strings.ToUpper(key)] = ent.value
+	}
+	return nil
+}
 
-	if curQuote != 0 {
-		return fmt.Errorf("line %d: unterminated quoted value", d.line)
+// checkEscapes returns an error if the double-quoted value contains an unknown escape sequence.
+func (d *DefaultDecoder) checkEscapes(value string) error {
+	end := d.findTerminator(value[1:], prefixDoubleQuote)
+	if end < 0 {
+		return nil
+	}
 
+	value = value[1 : end+1]
+	for i := 0; i < len(value)-1; i++ {
+		if value[i] != '\\' {
+			continue
+		}
+		switch c := value[i+1]; c {
+		case 'n', 'r', '\\', '"', '$':
+			i++
+		default:
+			return fmt.Errorf("unknown escape sequence \\%c", c)
+		}
 	}
 	return nil
 }
 
-// addEnv adds the key and value to the environment.
-func addEnv(key, value string, v map[string]any) {
-	if strings.HasPrefix(key, "export ") {
-		_ = os.Setenv(key[7:], value)
-		return
+// renderTemplate renders value as a text/template template with vars as data.
+func renderTemplate(value string, vars map[string]any) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RawValue returns the value of the key as written in the decoded file(s),
+// before quotes are removed and escape sequences are processed.
+// Raw values are only retained if KeepRawValues is enabled.
+func (d *DefaultDecoder) RawValue(key string) (string, bool) {
+	raw, ok := d.rawValues[strings.ToUpper(key)]
+	return raw, ok
+}
+
+func (d *DefaultDecoder) appendValue(existing, value string) string {
+	if existing == "" {
+		return value
+	}
+
+	sep := d.AppendSeparator
+	if sep == "" {
+		sep = ","
+	}
+	return existing + sep + value
+}
+
+// trimValue trims the parsed value if TrimValues is enabled
+// and the value was not single-quoted or backtick-quoted.
+func (d *DefaultDecoder) trimValue(value string, quote byte) string {
+	if d.TrimValues && quote != prefixSingleQuote && quote != prefixBacktick {
+		return strings.TrimSpace(value)
+	}
+	return value
+}
+
+// quoteAfterLine returns the quote of the multi-line quoted value that is still open after
+// the line, or 0 if there's none, following the rules of DecodeReader.
+// quote is the quote of the value that is open before the line, if any.
+func quoteAfterLine(line string, quote byte) byte {
+	var d DefaultDecoder
+	if quote != 0 {
+		if d.findTerminator(strings.TrimSuffix(line, "\n"), quote) == -1 {
+			return quote
+		}
+		return 0
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return 0
+	}
+	_, val, ok := strings.Cut(line, "=")
+	if !ok {
+		_, val, _ = strings.Cut(line, ":")
+	}
+	val = strings.TrimSpace(val)
+	if q, isQuoted := isPrefixQuoted(val); isQuoted && d.findTerminator(val[1:], q) == -1 {
+		return q
 	}
-	v[strings.ToUpper(key)] = value
+	return 0
 }
 
 // findTerminator finds the terminator of a quote in a string
 // and returns the index of the terminator.
+// Backtick-quoted values are fully literal, so their terminator cannot be escaped.
 func (d *DefaultDecoder) findTerminator(str string, quote byte) int {
+	if quote == prefixBacktick {
+		return strings.IndexByte(str, quote)
+	}
+
 	previousCharIsEscape := false
 	for i := 0; i < len(str); i++ {
 		char := str[i]
@@ -146,36 +434,87 @@ func parseValue(value string) string {
 	// remove leading and trailing spaces
 	value = strings.TrimSpace(value)
 	if len(value) > 1 {
-		if quote, ok := isPrefixQuoted(value); ok {
+		var d DefaultDecoder
+		// a value whose first quote is closed before its end, e.g. "a","b", is kept as is
+		if quote, ok := isPrefixQuoted(value); ok && d.findTerminator(value[1:], quote) == len(value)-2 {
 			// remove quotes
 			value = value[1 : len(value)-1]
 
 			if quote == prefixDoubleQuote {
-				value = escapeRegex.ReplaceAllStringFunc(value, func(s string) string {
-					c := strings.TrimPrefix(s, "\\")
-					switch c {
-					case "n":
-						return "\n"
-					case "r":
-						return "\r"
-					default:
-						return s
-					}
-				})
-				// unescape characters
-				value = unescapeCharsRegex.ReplaceAllString(value, "$1")
+				value = expandEscapes(value)
 			}
 		}
 	}
 	return value
 }
 
+// expandEscapes processes the escape sequences of a double-quoted value.
+func expandEscapes(value string) string {
+	value = escapeRegex.ReplaceAllStringFunc(value, func(s string) string {
+		c := strings.TrimPrefix(s, "\\")
+		switch c {
+		case "n":
+			return "\n"
+		case "r":
+			return "\r"
+		default:
+			return s
+		}
+	})
+	// unescape characters
+	return unescapeCharsRegex.ReplaceAllString(value, "$1")
+}
+
+// parseGodotenvValue parses a value the same way github.com/joho/godotenv does.
+// vars holds the values decoded so far, which are used to expand variables.
+func (d *DefaultDecoder) parseGodotenvValue(value string, quote byte, vars map[string]any) string {
+	value = strings.TrimSpace(value)
+
+	switch quote {
+	case prefixSingleQuote, prefixDoubleQuote:
+		if end := d.findTerminator(value[1:], quote); end >= 0 {
+			value = value[1 : end+1]
+		}
+		if quote == prefixSingleQuote {
+			return value
+		}
+		return expandVariables(expandEscapes(value), vars)
+	}
+
+	// inline comments must be preceded by whitespace, e.g. FOO=foo#bar is foo#bar
+	for i := len(value) - 1; i > 0; i-- {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return expandVariables(strings.TrimSpace(value), vars)
+}
+
+// expandVariables expands $VAR and ${VAR} in value with the values in vars,
+// falling back to environment variables. A reference escaped as \$VAR is kept literally.
+func expandVariables(value string, vars map[string]any) string {
+	return expandVarRegex.ReplaceAllStringFunc(value, func(s string) string {
+		submatch := expandVarRegex.FindStringSubmatch(s)
+		if submatch[1] == "\\" {
+			return s[1:]
+		}
+		if name := submatch[4]; name != "" {
+			if val, ok := vars[name]; ok {
+				return fmt.Sprint(val)
+			}
+			return os.Getenv(name)
+		}
+		return s
+	})
+}
+
 func isPrefixQuoted(s string) (byte, bool) {
 	if s == "" {
 		return 0, false
 	}
 	switch quote := s[0]; quote {
-	case prefixDoubleQuote, prefixSingleQuote:
+	case prefixDoubleQuote, prefixSingleQuote, prefixBacktick:
 		return quote, true
 	default:
 		return 0, false
@@ -187,5 +526,5 @@ func isQuoted(s string) bool {
 		return false
 	}
 
-	return s[0] == s[len(s)-1] && (s[0] == prefixDoubleQuote || s[0] == prefixSingleQuote)
+	return s[0] == s[len(s)-1] && (s[0] == prefixDoubleQuote || s[0] == prefixSingleQuote || s[0] == prefixBacktick)
 }
@@ -0,0 +1,202 @@
+package dotenv_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/go-dotenv"
+)
+
+func TestDefaultDecoder_OnEntry(t *testing.T) {
+	type entry struct {
+		key, value string
+		line       int
+	}
+
+	var entries []entry
+	decoder := &dotenv.DefaultDecoder{
+		OnEntry: func(key, value string, line int) {
+			entries = append(entries, entry{key, value, line})
+		},
+	}
+
+	data, err := os.ReadFile("fixtures/invalid.env")
+	require.NoError(t, err)
+
+	err = decoder.Decode(data, map[string]any{})
+	assert.EqualError(t, err, "line 7: key cannot contain spaces")
+
+	data, err = os.ReadFile("fixtures/quoted.env")
+	require.NoError(t, err)
+
+	entries = nil
+	err = decoder.Decode(data, map[string]any{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []entry{
+		{"OPTION_A", "1", 1},
+		{"OPTION_B", "2", 2},
+		{"OPTION_C", "", 3},
+		{"OPTION_D", "\\n", 4},
+		{"OPTION_E", "1", 5},
+		{"OPTION_F", "2", 6},
+		{"OPTION_G", "", 7},
+		{"OPTION_H", "\n", 8},
+		{"OPTION_I", "echo 'asd'", 9},
+		{"OPTION_J", "first line\nsecond line\nthird line\nand so on", 10},
+		{"OPTION_K", "Test#123", 14},
+		{"OPTION_Z", "last value", 15},
+	}, entries)
+}
+
+func TestDefaultDecoder_GodotenvCompat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		key      string
+		expected string
+	}{
+		{"plain", "FOO=bar", "FOO", "bar"},
+		{"surrounding spaces", "FOO = bar", "FOO", "bar"},
+		{"yaml style", "FOO: bar", "FOO", "bar"},
+		{"double quoted", `FOO="bar"`, "FOO", "bar"},
+		{"single quoted", `FOO='bar'`, "FOO", "bar"},
+		{"escaped double quote", `FOO="escaped\"bar"`, "FOO", `escaped"bar`},
+		{"single quotes in double quotes", `FOO="'d'"`, "FOO", "'d'"},
+		{"newline in double quotes", `FOO="bar\nbaz"`, "FOO", "bar\nbaz"},
+		{"escaped newline in double quotes", `FOO="bar\\nbaz"`, "FOO", `bar\nbaz`},
+		{"newline in single quotes", `FOO='bar\nbaz'`, "FOO", `bar\nbaz`},
+		{"equals in value", "FOO=foobar=", "FOO", "foobar="},
+		{"inline comment", "FOO=bar # this is foo", "FOO", "bar"},
+		{"hash without space", "FOO=foo#bar", "FOO", "foo#bar"},
+		{"hash in double quotes", `FOO="bar#baz" # comment`, "FOO", "bar#baz"},
+		{"hash in single quotes", `FOO='bar#baz' # comment`, "FOO", "bar#baz"},
+		{"hashes in double quotes", `FOO="bar#baz#bang" # comment`, "FOO", "bar#baz#bang"},
+		{"export", "export OPTION_A=2", "OPTION_A", "2"},
+		{"expand unquoted", "FOO=test\nBAR=$FOO", "BAR", "test"},
+		{"expand braces", "FOO=test\nBAR=${FOO}bar", "BAR", "testbar"},
+		{"expand double quoted", "FOO=test\nBAR=\"quote $FOO\"", "BAR", "quote test"},
+		{"no expand single quoted", "FOO=test\nBAR='quote $FOO'", "BAR", "quote $FOO"},
+		{"escaped expansion", "FOO=test\nBAR=\"foo\\$FOO\"", "BAR", "foo$FOO"},
+		{"undefined expansion", "BAR=$UNDEFINED_GODOTENV_VAR", "BAR", ""},
+		{"multi-line double quoted", "FOO=\"bar\nbaz\"", "FOO", "bar\nbaz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := &dotenv.DefaultDecoder{GodotenvCompat: true}
+			v := map[string]any{}
+			err := decoder.Decode([]byte(tt.input), v)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, v[tt.key])
+		})
+	}
+
+	decoder := &dotenv.DefaultDecoder{GodotenvCompat: true}
+	err := decoder.Decode([]byte("INVALID LINE"), map[string]any{})
+	assert.EqualError(t, err, "line 1: key cannot contain spaces")
+}
+
+func TestDefaultDecoder_StrictEscapes(t *testing.T) {
+	data, err := os.ReadFile("fixtures/escapes.env")
+	require.NoError(t, err)
+
+	v := map[string]any{}
+	err = (&dotenv.DefaultDecoder{}).Decode(data, v)
+	require.NoError(t, err)
+	assert.Equal(t, `tab\tseparated`, v["VALID"])
+	assert.Equal(t, "fooqbar", v["UNKNOWN_ESCAPE"])
+
+	err = (&dotenv.DefaultDecoder{StrictEscapes: true}).Decode(data, map[string]any{})
+	var parseErr *dotenv.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Line)
+	assert.EqualError(t, err, `line 2: unknown escape sequence \q`)
+}
+
+func TestDefaultDecoder_TemplateValues(t *testing.T) {
+	data, err := os.ReadFile("fixtures/template.env")
+	require.NoError(t, err)
+
+	v := map[string]any{}
+	err = (&dotenv.DefaultDecoder{TemplateValues: true}).Decode(data, v)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:8080", v["URL"])
+	assert.Equal(t, "http://localhost:8080/api", v["QUOTED_URL"])
+	assert.Equal(t, "{{.HOST}}", v["LITERAL"])
+
+	v = map[string]any{}
+	err = (&dotenv.DefaultDecoder{}).Decode(data, v)
+	require.NoError(t, err)
+	assert.Equal(t, "{{.HOST}}:{{.PORT}}", v["URL"])
+
+	err = (&dotenv.DefaultDecoder{TemplateValues: true}).Decode([]byte("HOST=localhost\n\nURL={{.HOST}}:{{.PORT}}"), map[string]any{})
+	var parseErr *dotenv.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.ErrorContains(t, err, `map has no entry for key "PORT"`)
+}
+
+func TestDefaultDecoder_BareKeysAsTrue(t *testing.T) {
+	env := dotenv.New()
+	err := env.LoadWithDecoder(&dotenv.DefaultDecoder{BareKeysAsTrue: true}, "fixtures/bare_keys.env")
+	require.NoError(t, err)
+
+	assert.True(t, env.GetBool("DEBUG"))
+	assert.False(t, env.GetBool("VERBOSE"))
+	assert.Equal(t, "info", env.GetString("LOG_LEVEL"))
+
+	env = dotenv.New()
+	require.NoError(t, env.Load("fixtures/bare_keys.env"))
+	assert.True(t, env.IsSet("DEBUG"))
+	assert.False(t, env.GetBool("DEBUG"))
+}
+
+func TestDefaultDecoder_ContinueOnError(t *testing.T) {
+	data, err := os.ReadFile("fixtures/invalid.env")
+	require.NoError(t, err)
+
+	v := map[string]any{}
+	err = (&dotenv.DefaultDecoder{ContinueOnError: true}).Decode(data, v)
+	var parseErr *dotenv.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 7, parseErr.Line)
+	assert.Equal(t, "fooz", v["BAR"])
+	assert.Equal(t, "\n  a\n  mult line\n  value\n", v["FOO"])
+
+	env := dotenv.New()
+	// the decoder is kept for subsequent loads
+	err = env.LoadWithDecoder(&dotenv.DefaultDecoder{ContinueOnError: true}, "fixtures/invalid.env")
+	require.ErrorAs(t, err, &parseErr)
+	// the valid lines are loaded
+	assert.Equal(t, "fooz", env.GetString("BAR"))
+	assert.Equal(t, "fixtures/invalid.env", env.ConfigFileUsed())
+	require.ErrorAs(t, env.Load("fixtures/invalid.env"), &parseErr)
+	assert.Equal(t, "fooz", env.GetString("BAR"))
+
+	errs := env.LoadCollect("fixtures/invalid.env", "fixtures/unknown.env", "fixtures/plain.env")
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "line 7: key cannot contain spaces")
+	assert.ErrorIs(t, errs[1], os.ErrNotExist)
+
+	assert.Equal(t, "fooz", env.GetString("BAR"))
+	assert.Equal(t, "3", env.GetString("OPTION_C"))
+
+	// only the files that loaded, even partly, are read again by Reload
+	err = env.Reload()
+	assert.EqualError(t, err, "line 7: key cannot contain spaces")
+	assert.Equal(t, "fooz", env.GetString("BAR"))
+	assert.Equal(t, "3", env.GetString("OPTION_C"))
+	assert.Equal(t, "fixtures/plain.env", env.ConfigFileUsed())
+
+	// the loaded files are kept if none of the files loaded
+	errs = env.LoadCollect("fixtures/unknown.env")
+	require.Len(t, errs, 1)
+	assert.Equal(t, "fixtures/plain.env", env.ConfigFileUsed())
+	err = env.Reload()
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, "fooz", env.GetString("BAR"))
+}
@@ -0,0 +1,121 @@
+package dotenv
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval used by Watch if the interval is not positive.
+const DefaultWatchInterval = time.Second
+
+// Watch polls the config file(s) of the last load for changes every interval.
+// If interval is not positive, DefaultWatchInterval is used.
+// When any of the files changes, all of them are reloaded in their original order,
+// so the precedence of later files is preserved and keys removed from the files are dropped.
+// onReload, if not nil, is called after every reload with its error, if any.
+// It returns a function to stop watching.
+func Watch(interval time.Duration, onReload func(err error)) (stop func()) {
+	return GetDotEnv().Watch(interval, onReload)
+}
+
+func (e *DotEnv) Watch(interval time.Duration, onReload func(err error)) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	done := make(chan struct{})
+	snapshot := e.snapshotFiles()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := e.snapshotFiles()
+				if snapshotEqual(snapshot, current) {
+					continue
+				}
+				snapshot = current
+
+				err := e.reload()
+				if onReload != nil {
+					onReload(err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// ReloadOnSignal reloads the config file(s) of the last load whenever the process
+// receives one of the signals, e.g. syscall.SIGHUP.
+// It returns a function to stop handling the signals.
+func ReloadOnSignal(sigs ...os.Signal) (stop func()) {
+	return GetDotEnv().ReloadOnSignal(sigs...)
+}
+
+func (e *DotEnv) ReloadOnSignal(sigs ...os.Signal) (stop func()) {
+	done := make(chan struct{})
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				_ = e.reload()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// snapshotFiles returns the contents of the files of the last load.
+// Files that cannot be read and stdin have a nil content.
+func (e *DotEnv) snapshotFiles() [][]byte {
+	e.mu.RLock()
+	files := e.loadedFiles
+	e.mu.RUnlock()
+
+	snapshot := make([][]byte, len(files))
+	for i, file := range files {
+		if file == StdinConfigFile {
+			// stdin is only read once, so it never changes
+			continue
+		}
+		snapshot[i], _ = e.readFile(file)
+	}
+	return snapshot
+}
+
+func snapshotEqual(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/go-dotenv"
+)
+
+func TestDotEnv_Watch(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	override := filepath.Join(dir, "override.env")
+	require.NoError(t, os.WriteFile(base, []byte("HOST=localhost\nPORT=8080\nDEBUG=false\n"), 0600))
+	require.NoError(t, os.WriteFile(override, []byte("PORT=9090\nDEBUG=true\n"), 0600))
+
+	env := dotenv.New()
+	require.NoError(t, env.Load(base, override))
+	assert.Equal(t, 9090, env.GetInt("PORT"))
+
+	reloaded := make(chan error, 1)
+	stop := env.Watch(10*time.Millisecond, func(err error) {
+		reloaded <- err
+	})
+	defer stop()
+
+	require.NoError(t, os.WriteFile(override, []byte("PORT=7070\nLOG_LEVEL=debug\n"), 0600))
+
+	select {
+	case err := <-reloaded:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+
+	assert.Equal(t, "localhost", env.GetString("HOST"))
+	assert.Equal(t, 7070, env.GetInt("PORT"))
+	assert.Equal(t, "debug", env.GetString("LOG_LEVEL"))
+	// DEBUG was removed from the override, so the base value applies
+	assert.Equal(t, "false", env.GetString("DEBUG"))
+}
+
+func TestDotEnv_Watch_nonPositiveInterval(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("PORT=8080\n"), 0600))
+
+	env := dotenv.New()
+	require.NoError(t, env.Load(file))
+
+	reloaded := make(chan error, 1)
+	stop := env.Watch(0, func(err error) {
+		reloaded <- err
+	})
+	defer stop()
+
+	require.NoError(t, os.WriteFile(file, []byte("PORT=9090\n"), 0600))
+
+	select {
+	case err := <-reloaded:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	assert.Equal(t, 9090, env.GetInt("PORT"))
+}
+
+func TestDotEnv_Watch_concurrentLoad(t *testing.T) {
+	watched := filepath.Join(t.TempDir(), "watched.env")
+	require.NoError(t, os.WriteFile(watched, []byte("PORT=8080\n"), 0600))
+
+	env := dotenv.New()
+	require.NoError(t, env.Load(watched))
+
+	stop := env.Watch(time.Millisecond, nil)
+	defer stop()
+
+	// reloads triggered by the watcher run while the config is loaded
+	for i := 0; i < 100; i++ {
+		require.NoError(t, os.WriteFile(watched, []byte("PORT="+strconv.Itoa(8000+i)+"\n"), 0600))
+		require.NoError(t, env.LoadReader(strings.NewReader("HOST=localhost\n")))
+		time.Sleep(100 * time.Microsecond)
+	}
+	assert.Equal(t, "localhost", env.GetString("HOST"))
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/go-dotenv"
+)
+
+func TestDotEnv_ReloadOnSignal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("PORT=8080\n"), 0600))
+
+	env := dotenv.New()
+	require.NoError(t, env.Load(file))
+
+	stop := env.ReloadOnSignal(syscall.SIGHUP)
+	defer stop()
+
+	require.NoError(t, os.WriteFile(file, []byte("PORT=9090\n"), 0600))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		return env.GetInt("PORT") == 9090
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(2), env.Stats().Loads)
+}
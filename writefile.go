@@ -4,6 +4,7 @@ package dotenv
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/google/renameio"
 )
@@ -11,3 +12,22 @@ import (
 func WriteFile(filename string, data []byte, perm os.FileMode) error {
 	return renameio.WriteFile(filename, data, perm)
 }
+
+// syncFile flushes the file and its directory to stable storage,
+// so a file that was just written or renamed survives a crash.
+func syncFile(filename string) error {
+	if err := syncPath(filename); err != nil {
+		return err
+	}
+	return syncPath(filepath.Dir(filename))
+}
+
+func syncPath(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
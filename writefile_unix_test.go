@@ -0,0 +1,34 @@
+//go:build unix
+
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/go-dotenv"
+)
+
+func TestDotEnv_SetFilePerm(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	env := dotenv.New()
+	env.SetConfigFile(file)
+	require.NoError(t, env.Write("SECRET", "s3cr3t"))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, dotenv.DefaultFilePerm, info.Mode().Perm())
+
+	// the permission of an existing file is replaced
+	env.SetFilePerm(0640)
+	require.NoError(t, env.Save())
+
+	info, err = os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
@@ -11,3 +11,16 @@ import (
 func WriteFile(filename string, data []byte, perm os.FileMode) error {
 	return os.WriteFile(filename, data, perm)
 }
+
+// syncFile flushes the file to stable storage.
+// Directories cannot be synced on windows, so the rename of the file is not guaranteed to be durable.
+func syncFile(filename string) error {
+	// FlushFileBuffers requires write access
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}